@@ -1,8 +1,12 @@
 package analyzer
 
 import (
-	_ "context"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"os"
 	"strings"
 	"time"
 
@@ -11,103 +15,281 @@ import (
 	"NebulaChallenge/utils"
 )
 
+// Intervalos de polling por defecto
+const (
+	DefaultPollInterval           = 5 * time.Second
+	DefaultInProgressPollInterval = 10 * time.Second
+)
+
+// ErrMaxWaitExceeded indica que el análisis superó la espera máxima configurada
+var ErrMaxWaitExceeded = errors.New("analysis exceeded maximum wait")
+
 // Analyzer orquesta el análisis de SSL
 type Analyzer struct {
-	client *client.Client
+	scanner Scanner
+	logger  *slog.Logger
+
+	pollInterval           time.Duration
+	inProgressPollInterval time.Duration
+	maxWait                time.Duration // 0 = sin límite
+
+	fromCache   bool
+	cacheMaxAge int // Horas; 0 = cualquier antigüedad
+
+	detailConcurrency int
+	progress          progressFunc // nil = imprimir el progreso en out
+	out               io.Writer    // Mensajes y progreso de Run
+	hostDone          func(entry *models.HostResult)
+	batchStart        func(start BatchStart)
 }
 
 // NewAnalyzer crea una nueva instancia del analizador
-func NewAnalyzer() *Analyzer {
-	return &Analyzer{
-		client: client.NewClient(),
+func NewAnalyzer(opts ...Option) *Analyzer {
+	a := &Analyzer{
+		logger:                 slog.New(slog.DiscardHandler),
+		out:                    os.Stderr,
+		pollInterval:           DefaultPollInterval,
+		inProgressPollInterval: DefaultInProgressPollInterval,
+		detailConcurrency:      DefaultDetailConcurrency,
+	}
+
+	for _, opt := range opts {
+		opt(a)
 	}
+
+	if a.scanner == nil {
+		a.scanner = client.NewClient(client.WithLogger(a.logger))
+	}
+
+	return a
 }
 
-// Run ejecuta el análisis completo de un host
-func (a *Analyzer) Run(host string, publish bool) (*models.Host, error) {
+// progressFunc recibe cada estado intermedio durante el polling
+type progressFunc func(result *models.Host)
+
+// Run ejecuta el análisis completo de un host.
+// Si ctx se cancela durante el polling, devuelve el último resultado parcial
+// obtenido junto con un error que envuelve ctx.Err().
+func (a *Analyzer) Run(ctx context.Context, host string, publish bool) (*models.Host, error) {
 	// 1. Validar el host
-	sanitizedHost := utils.SanitizeHost(host)
-	if err := utils.ValidateHost(sanitizedHost); err != nil {
-		return nil, fmt.Errorf("invalid host: %w", err)
+	req, err := prepareRequest(host, publish)
+	if err != nil {
+		return nil, err
 	}
 
 	// 2. Verificar disponibilidad del servicio
-	info, err := a.client.GetInfo()
+	if provider, ok := optional[InfoProvider](a.scanner); ok {
+		info, err := provider.GetInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("SSL Labs service unavailable: %w", err)
+		}
+
+		fmt.Fprintf(a.out, "SSL Labs API v%s (Criteria: %s)\n", info.Version, info.CriteriaVersion)
+		fmt.Fprintf(a.out, "Max concurrent assessments: %d\n", info.MaxAssessments)
+		fmt.Fprintf(a.out, "Current assessments: %d\n\n", info.CurrentAssessments)
+	}
+
+	// 3. Iniciar análisis y hacer polling hasta que termine
+	if a.fromCache {
+		fmt.Fprintf(a.out, "Looking for a cached report of %s (engine: %s)...\n", req.Target(), a.scanner.Name())
+	} else {
+		fmt.Fprintf(a.out, "Starting analysis for %s (engine: %s)...\n", req.Target(), a.scanner.Name())
+	}
+	progress := a.progress
+	if progress == nil {
+		progress = a.printProgress
+	}
+	result, err := a.assess(ctx, req, progress)
+	if a.progress == nil {
+		fmt.Fprint(a.out, "\r"+strings.Repeat(" ", 100)+"\r") // Limpiar línea de progreso
+	}
 	if err != nil {
-		return nil, fmt.Errorf("SSL Labs service unavailable: %w", err)
+		return result, err
 	}
 
-	fmt.Printf("SSL Labs API v%s (Criteria: %s)\n", info.Version, info.CriteriaVersion)
-	fmt.Printf("Max concurrent assessments: %d\n", info.MaxAssessments)
-	fmt.Printf("Current assessments: %d\n\n", info.CurrentAssessments)
+	fmt.Fprintln(a.out, "\n Analysis complete!")
+
+	for _, ep := range result.Endpoints {
+		if ep.DetailsError != "" {
+			fmt.Fprintf(a.out, "Warning: could not fetch details for endpoint %s: %s\n", ep.IPAddress, ep.DetailsError)
+		}
+	}
+
+	return result, nil
+}
+
+// prepareRequest sanitiza y valida el host recibido. Un puerto explícito se
+// conserva para los backends que lo usan.
+func prepareRequest(host string, publish bool) (models.ScanRequest, error) {
+	sanitizedHost, port, err := utils.SplitHostPort(host, 0)
+	if err == nil {
+		err = utils.ValidateHost(sanitizedHost)
+	}
+	if err != nil {
+		return models.ScanRequest{}, fmt.Errorf("invalid host: %w", err)
+	}
+
+	return models.ScanRequest{Host: sanitizedHost, Port: port, Publish: publish}, nil
+}
+
+// assess inicia el análisis de un host ya validado y espera a que termine.
+// progress puede ser nil si no se quiere mostrar el progreso.
+func (a *Analyzer) assess(ctx context.Context, req models.ScanRequest, progress progressFunc) (*models.Host, error) {
+	if a.maxWait > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, a.maxWait, ErrMaxWaitExceeded)
+		defer cancel()
+	}
 
-	// 3. Iniciar análisis
-	fmt.Printf("Starting analysis for %s...\n", sanitizedHost)
-	result, err := a.client.StartAnalysis(sanitizedHost, publish)
+	status, err := a.start(ctx, req)
 	if err != nil {
+		if ctx.Err() != nil {
+			return status, stopError(ctx)
+		}
 		return nil, fmt.Errorf("error starting analysis: %w", err)
 	}
 
-	// 4. Si ya está listo, retornar
-	if client.IsAnalysisComplete(result.Status) {
-		if result.Status == "ERROR" {
-			return nil, fmt.Errorf("analysis failed: %s", result.StatusMessage)
+	// Si no está listo, hacer polling hasta que termine
+	if !client.IsAnalysisComplete(status.Status) {
+		status, err = a.pollAnalysis(ctx, req, status, progress)
+		if err != nil {
+			return status, err
 		}
-		return result, nil
 	}
 
-	// 5. Hacer polling hasta que termine
-	fmt.Println("Analysis in progress...")
-	return a.pollAnalysis(sanitizedHost)
+	if status.Status == "ERROR" {
+		return nil, fmt.Errorf("analysis failed: %s", status.StatusMessage)
+	}
+
+	result, err := a.scanner.Result(ctx, req, status)
+	if err != nil {
+		if ctx.Err() != nil {
+			return status, stopError(ctx)
+		}
+		return status, fmt.Errorf("error fetching result: %w", err)
+	}
+
+	// Completar los endpoints que llegaron sin detalles
+	a.enrich(ctx, req, result)
+	if ctx.Err() != nil {
+		return result, stopError(ctx)
+	}
+
+	return result, nil
+}
+
+// start obtiene el estado inicial del análisis. Con caché habilitada usa un
+// reporte reciente si el backend lo tiene; si el backend ya inició una
+// evaluación al no encontrarlo (SSL Labs lo hace con fromCache), se continúa
+// con esa. En otro caso inicia un análisis nuevo.
+func (a *Analyzer) start(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	if !a.fromCache {
+		return a.scanner.Start(ctx, req)
+	}
+
+	provider, ok := optional[CacheProvider](a.scanner)
+	if !ok {
+		a.logger.Warn("engine does not support cached results, starting a new analysis", "engine", a.scanner.Name())
+		return a.scanner.Start(ctx, req)
+	}
+
+	cached, err := provider.Cached(ctx, req, a.cacheMaxAge)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case cached.Status == "READY" && a.withinMaxAge(cached):
+		a.logger.Info("using cached report", "host", req.Target(), "age", cached.Age().Round(time.Minute))
+		return cached, nil
+	case cached.Status == "READY":
+		a.logger.Info("cached report older than max age, starting a new analysis", "host", req.Target(), "age", cached.Age().Round(time.Minute))
+		return a.scanner.Start(ctx, req)
+	case !client.IsAnalysisComplete(cached.Status):
+		a.logger.Info("no cached report, assessment already started", "host", req.Target())
+		return cached, nil
+	default:
+		a.logger.Info("cached analysis failed, starting a new analysis", "host", req.Target(), "status", cached.Status)
+		return a.scanner.Start(ctx, req)
+	}
 }
 
-// pollAnalysis hace polling periódico hasta que el análisis termine
-func (a *Analyzer) pollAnalysis(host string) (*models.Host, error) {
-	pollInterval := 5 * time.Second
+// withinMaxAge comprueba la antigüedad del reporte en caché por si el
+// servidor (p. ej. un mirror) no respeta maxAge
+func (a *Analyzer) withinMaxAge(host *models.Host) bool {
+	if a.cacheMaxAge <= 0 {
+		return true
+	}
+	return host.Age() <= time.Duration(a.cacheMaxAge)*time.Hour
+}
+
+// pollAnalysis hace polling periódico hasta que el análisis termine.
+// last es el último estado conocido y se devuelve como resultado parcial
+// si ctx se cancela antes de que el análisis complete.
+func (a *Analyzer) pollAnalysis(ctx context.Context, req models.ScanRequest, last *models.Host, progress progressFunc) (*models.Host, error) {
+	pollInterval := a.pollInterval
 	inProgress := false
 
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+
 	for {
-		time.Sleep(pollInterval)
+		select {
+		case <-ctx.Done():
+			return last, stopError(ctx)
+		case <-timer.C:
+		}
 
-		result, err := a.client.CheckAnalysis(host)
+		result, err := a.scanner.Poll(ctx, req)
 		if err != nil {
+			if ctx.Err() != nil {
+				return last, stopError(ctx)
+			}
 			return nil, fmt.Errorf("error checking analysis: %w", err)
 		}
+		last = result
+
+		a.logger.Debug("analysis status", "host", req.Target(), "status", result.Status)
 
 		// Mostrar progreso
-		a.printProgress(result)
+		if progress != nil {
+			progress(result)
+		}
 
 		// Verificar si terminó
 		if client.IsAnalysisComplete(result.Status) {
-			fmt.Print("\r" + strings.Repeat(" ", 100) + "\r") // Limpiar línea
-
-			if result.Status == "ERROR" {
-				return nil, fmt.Errorf("analysis failed: %s", result.StatusMessage)
-			}
-
-			fmt.Println("\n Analysis complete!")
 			return result, nil
 		}
 
 		// Ajustar intervalo cuando entra en progreso
 		if result.Status == "IN_PROGRESS" && !inProgress {
 			inProgress = true
-			pollInterval = 10 * time.Second
+			pollInterval = a.inProgressPollInterval
 		}
+		timer.Reset(pollInterval)
+	}
+}
+
+// stopError construye el error a devolver cuando ctx terminó antes que el
+// análisis, distinguiendo la espera máxima de una cancelación
+func stopError(ctx context.Context) error {
+	if errors.Is(context.Cause(ctx), ErrMaxWaitExceeded) {
+		return fmt.Errorf("analysis stopped: %w", ErrMaxWaitExceeded)
 	}
+	return fmt.Errorf("analysis cancelled: %w", ctx.Err())
 }
 
 // printProgress muestra el progreso actual
 func (a *Analyzer) printProgress(result *models.Host) {
-	fmt.Printf("\rStatus: %-15s", result.Status)
+	fmt.Fprintf(a.out, "\rStatus: %-15s", result.Status)
 
 	if len(result.Endpoints) > 0 {
-		fmt.Print(" | Endpoints: ")
+		fmt.Fprint(a.out, " | Endpoints: ")
 		for i, ep := range result.Endpoints {
 			if i > 0 {
-				fmt.Print(", ")
+				fmt.Fprint(a.out, ", ")
 			}
-			fmt.Printf("%s (%d%%)", ep.IPAddress, ep.Progress)
+			fmt.Fprintf(a.out, "%s (%d%%)", ep.IPAddress, ep.Progress)
 		}
 	}
 }
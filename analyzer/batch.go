@@ -0,0 +1,153 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"NebulaChallenge/client"
+	"NebulaChallenge/models"
+)
+
+// BatchStart describe un batch que empieza, para mostrarlo antes de que
+// termine el primer host
+type BatchStart struct {
+	Hosts              int
+	Workers            int
+	Engine             string
+	Info               *models.Info // nil si el backend no informa la API
+	MaxAssessments     int          // 0 = sin límite informado
+	CurrentAssessments int
+}
+
+// RunBatch analiza varios hosts con un pool de workers acotado.
+// El número de workers es el menor entre concurrency y, si el backend los
+// informa, las evaluaciones que la API permite iniciar
+// (MaxAssessments - CurrentAssessments).
+// Los resultados se devuelven en el mismo orden que hosts.
+func (a *Analyzer) RunBatch(ctx context.Context, hosts []string, publish bool, concurrency int) (*models.BatchResult, error) {
+	start := BatchStart{Hosts: len(hosts), Engine: a.scanner.Name()}
+
+	if provider, ok := optional[InfoProvider](a.scanner); ok {
+		info, err := provider.GetInfo(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("SSL Labs service unavailable: %w", err)
+		}
+
+		start.Info = info
+		start.MaxAssessments, start.CurrentAssessments = info.MaxAssessments, info.CurrentAssessments
+		if rl := provider.RateLimit(); rl != nil {
+			start.MaxAssessments, start.CurrentAssessments = rl.MaxAssessments, rl.CurrentAssessments
+		}
+	}
+
+	workers := batchWorkers(concurrency, start.MaxAssessments, start.CurrentAssessments, len(hosts))
+	start.Workers = workers
+
+	a.logger.Debug("batch started", "hosts", start.Hosts, "workers", workers, "engine", start.Engine,
+		"max_assessments", start.MaxAssessments, "current_assessments", start.CurrentAssessments)
+	if a.batchStart != nil {
+		a.batchStart(start)
+	}
+
+	batch := &models.BatchResult{Results: make([]models.HostResult, len(hosts))}
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	done := 0
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entry := a.runBatchHost(ctx, hosts[i], publish)
+				batch.Results[i] = entry
+
+				mu.Lock()
+				done++
+				a.logger.Debug("batch host finished", "host", entry.Host,
+					"done", done, "total", len(hosts), "error", entry.Error)
+				if a.hostDone != nil {
+					a.hostDone(&entry)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// Encolar hosts hasta terminar o hasta que se cancele el contexto
+	next := 0
+enqueue:
+	for next < len(hosts) {
+		select {
+		case jobs <- next:
+			next++
+		case <-ctx.Done():
+			break enqueue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// Los hosts que no llegaron a encolarse quedan marcados como cancelados
+	for ; next < len(hosts); next++ {
+		batch.Results[next] = models.HostResult{
+			Host:  hosts[next],
+			Error: fmt.Sprintf("analysis cancelled: %v", ctx.Err()),
+		}
+	}
+
+	if ctx.Err() != nil {
+		return batch, fmt.Errorf("batch cancelled: %w", ctx.Err())
+	}
+
+	return batch, nil
+}
+
+// runBatchHost analiza un host del batch sin mostrar progreso por línea
+func (a *Analyzer) runBatchHost(ctx context.Context, host string, publish bool) models.HostResult {
+	entry := models.HostResult{Host: host}
+
+	req, err := prepareRequest(host, publish)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Host = req.Target()
+
+	result, err := a.assess(ctx, req, nil)
+	entry.Result = result
+	if err != nil {
+		entry.Error = err.Error()
+		entry.RateLimited = client.IsRateLimited(err)
+	}
+
+	return entry
+}
+
+// batchWorkers calcula cuántos workers usar para un batch
+func batchWorkers(concurrency, maxAssessments, currentAssessments, hosts int) int {
+	workers := concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	if maxAssessments > 0 {
+		available := maxAssessments - currentAssessments
+		if available < workers {
+			workers = available
+		}
+	}
+
+	if workers > hosts {
+		workers = hosts
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	return workers
+}
@@ -0,0 +1,360 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"NebulaChallenge/client"
+	"NebulaChallenge/models"
+)
+
+// CompositeMode indica cómo combina CompositeScanner los dos resultados
+type CompositeMode int
+
+const (
+	// CompositeMerge completa el resultado principal con los datos del secundario
+	CompositeMerge CompositeMode = iota
+	// CompositeCompare devuelve el resultado principal y registra las diferencias
+	CompositeCompare
+)
+
+// ParseCompositeMode convierte "merge" o "compare" en un CompositeMode
+func ParseCompositeMode(s string) (CompositeMode, error) {
+	switch s {
+	case "merge":
+		return CompositeMerge, nil
+	case "compare":
+		return CompositeCompare, nil
+	default:
+		return 0, fmt.Errorf("unknown composite mode: %s (use merge or compare)", s)
+	}
+}
+
+// CompositeScanner ejecuta dos backends sobre el mismo host y combina
+// (merge) o compara (compare) sus resultados. Las interfaces opcionales
+// (InfoProvider, CacheProvider, EndpointDetailer) se delegan en el backend
+// principal cuando este las implementa.
+type CompositeScanner struct {
+	primary   Scanner
+	secondary Scanner
+	mode      CompositeMode
+
+	// states guarda el progreso de los análisis en curso y differences el
+	// resultado de la comparación del último análisis de cada target. Ambos
+	// se indexan por ScanRequest.Target y solo se acceden con mu.
+	mu          sync.Mutex
+	states      map[string]compositeState
+	differences map[string][]string
+}
+
+// compositeState guarda el progreso de ambos backends para un host
+type compositeState struct {
+	primary      *models.Host
+	secondary    *models.Host
+	secondaryErr error
+}
+
+// NewCompositeScanner combina dos backends
+func NewCompositeScanner(primary, secondary Scanner, mode CompositeMode) *CompositeScanner {
+	return &CompositeScanner{
+		primary:     primary,
+		secondary:   secondary,
+		mode:        mode,
+		states:      make(map[string]compositeState),
+		differences: make(map[string][]string),
+	}
+}
+
+// Name identifica al backend compuesto
+func (c *CompositeScanner) Name() string {
+	return c.primary.Name() + "+" + c.secondary.Name()
+}
+
+// Start inicia el análisis en ambos backends. Un fallo del secundario no
+// detiene el análisis; se informa en las diferencias. La excepción es un
+// puerto que el secundario no analiza (client.ErrUnsupportedPort): el
+// resultado combinado diría que cubre un puerto que no cubre.
+func (c *CompositeScanner) Start(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	primary, err := c.primary.Start(ctx, req)
+	if err != nil {
+		return primary, err
+	}
+	return c.begin(ctx, req, primary)
+}
+
+// begin inicia el secundario una vez que el principal ya tiene estado
+func (c *CompositeScanner) begin(ctx context.Context, req models.ScanRequest, primary *models.Host) (*models.Host, error) {
+	state := compositeState{primary: primary}
+	state.secondary, state.secondaryErr = c.secondary.Start(ctx, req)
+	if errors.Is(state.secondaryErr, client.ErrUnsupportedPort) {
+		return nil, fmt.Errorf("%s: %w", c.secondary.Name(), state.secondaryErr)
+	}
+	c.setState(req, state)
+	return c.status(state), nil
+}
+
+// Poll consulta los backends que todavía no terminaron
+func (c *CompositeScanner) Poll(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	state, ok := c.state(req)
+	if !ok {
+		return nil, fmt.Errorf("analysis of %s was not started", req.Target())
+	}
+
+	if !client.IsAnalysisComplete(state.primary.Status) {
+		primary, err := c.primary.Poll(ctx, req)
+		if err != nil {
+			c.clearState(req)
+			return nil, err
+		}
+		state.primary = primary
+	}
+
+	if state.secondaryErr == nil && !client.IsAnalysisComplete(state.secondary.Status) {
+		state.secondary, state.secondaryErr = c.secondary.Poll(ctx, req)
+	}
+
+	// Un análisis fallido no llega a Result
+	if state.primary.Status == "ERROR" {
+		c.clearState(req)
+	} else {
+		c.setState(req, state)
+	}
+	return c.status(state), nil
+}
+
+// Result obtiene el resultado de ambos backends y los combina según el modo.
+// Al terminar se descarta el estado del análisis.
+func (c *CompositeScanner) Result(ctx context.Context, req models.ScanRequest, status *models.Host) (*models.Host, error) {
+	state, ok := c.state(req)
+	if !ok {
+		return nil, fmt.Errorf("analysis of %s was not started", req.Target())
+	}
+	defer c.clearState(req)
+
+	primary, err := c.primary.Result(ctx, req, state.primary)
+	if err != nil {
+		return nil, err
+	}
+
+	var secondary *models.Host
+	if state.secondaryErr == nil && state.secondary.Status == "READY" {
+		secondary, state.secondaryErr = c.secondary.Result(ctx, req, state.secondary)
+	} else if state.secondaryErr == nil {
+		state.secondaryErr = fmt.Errorf("analysis failed: %s", state.secondary.StatusMessage)
+	}
+
+	if state.secondaryErr != nil {
+		c.setDifferences(req, []string{fmt.Sprintf("%s failed: %v", c.secondary.Name(), state.secondaryErr)})
+		return primary, nil
+	}
+
+	switch c.mode {
+	case CompositeMerge:
+		c.setDifferences(req, nil)
+		return mergeHosts(primary, secondary), nil
+	default:
+		c.setDifferences(req, compareHosts(primary, secondary, c.primary.Name(), c.secondary.Name()))
+		return primary, nil
+	}
+}
+
+// Differences devuelve y descarta las diferencias registradas para target
+// (host o host:puerto, como ScanRequest.Target) en modo compare, o el fallo
+// del backend secundario. ok es false si el target no llegó a analizarse
+// con ambos backends.
+func (c *CompositeScanner) Differences(target string) (differences []string, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	differences, ok = c.differences[target]
+	delete(c.differences, target)
+	return differences, ok
+}
+
+// GetInfo delega en el backend principal (InfoProvider)
+func (c *CompositeScanner) GetInfo(ctx context.Context) (*models.Info, error) {
+	provider, ok := c.primary.(InfoProvider)
+	if !ok {
+		return nil, fmt.Errorf("engine %s does not report API info", c.primary.Name())
+	}
+	return provider.GetInfo(ctx)
+}
+
+// RateLimit delega en el backend principal (InfoProvider)
+func (c *CompositeScanner) RateLimit() *client.RateLimitInfo {
+	if provider, ok := c.primary.(InfoProvider); ok {
+		return provider.RateLimit()
+	}
+	return nil
+}
+
+// Cached busca el reporte en caché del backend principal (CacheProvider) e
+// inicia el secundario como en Start
+func (c *CompositeScanner) Cached(ctx context.Context, req models.ScanRequest, maxAge int) (*models.Host, error) {
+	provider, ok := c.primary.(CacheProvider)
+	if !ok {
+		return nil, fmt.Errorf("engine %s does not support cached results", c.primary.Name())
+	}
+	primary, err := provider.Cached(ctx, req, maxAge)
+	if err != nil {
+		return primary, err
+	}
+	return c.begin(ctx, req, primary)
+}
+
+// EndpointDetails delega en el backend principal (EndpointDetailer)
+func (c *CompositeScanner) EndpointDetails(ctx context.Context, req models.ScanRequest, ipAddress string) (*models.Endpoint, error) {
+	detailer, ok := c.primary.(EndpointDetailer)
+	if !ok {
+		return nil, fmt.Errorf("engine %s does not fetch endpoint details", c.primary.Name())
+	}
+	return detailer.EndpointDetails(ctx, req, ipAddress)
+}
+
+func (c *CompositeScanner) state(req models.ScanRequest) (compositeState, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state, ok := c.states[req.Target()]
+	return state, ok
+}
+
+func (c *CompositeScanner) setState(req models.ScanRequest, state compositeState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.states[req.Target()] = state
+}
+
+func (c *CompositeScanner) clearState(req models.ScanRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.states, req.Target())
+}
+
+func (c *CompositeScanner) setDifferences(req models.ScanRequest, differences []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.differences[req.Target()] = differences
+}
+
+// status devuelve el estado combinado: el host solo está listo cuando
+// ambos backends terminaron (o el secundario falló)
+func (c *CompositeScanner) status(state compositeState) *models.Host {
+	secondaryDone := state.secondaryErr != nil || client.IsAnalysisComplete(state.secondary.Status)
+	if client.IsAnalysisComplete(state.primary.Status) && !secondaryDone {
+		pending := *state.primary
+		pending.Status = "IN_PROGRESS"
+		return &pending
+	}
+	return state.primary
+}
+
+// mergeHosts completa primary con los endpoints, grados y detalles que solo
+// tiene secondary
+func mergeHosts(primary, secondary *models.Host) *models.Host {
+	merged := *primary
+	merged.Endpoints = slices.Clone(primary.Endpoints)
+
+	for _, sep := range secondary.Endpoints {
+		idx := slices.IndexFunc(merged.Endpoints, func(ep models.Endpoint) bool {
+			return ep.IPAddress == sep.IPAddress
+		})
+		if idx < 0 {
+			merged.Endpoints = append(merged.Endpoints, sep)
+			continue
+		}
+
+		ep := &merged.Endpoints[idx]
+		if ep.Grade == "" {
+			ep.Grade = sep.Grade
+			ep.GradeTrustIgnored = sep.GradeTrustIgnored
+		}
+		if ep.Details == nil {
+			ep.Details = sep.Details
+		}
+	}
+
+	return &merged
+}
+
+// compareHosts describe las diferencias por endpoint entre dos resultados
+func compareHosts(a, b *models.Host, nameA, nameB string) []string {
+	var diffs []string
+
+	for _, epA := range a.Endpoints {
+		idx := slices.IndexFunc(b.Endpoints, func(ep models.Endpoint) bool {
+			return ep.IPAddress == epA.IPAddress
+		})
+		if idx < 0 {
+			diffs = append(diffs, fmt.Sprintf("%s: only reported by %s", epA.IPAddress, nameA))
+			continue
+		}
+		epB := b.Endpoints[idx]
+
+		if epA.Grade != "" && epB.Grade != "" && epA.Grade != epB.Grade {
+			diffs = append(diffs, fmt.Sprintf("%s: grade %s (%s) vs %s (%s)",
+				epA.IPAddress, epA.Grade, nameA, epB.Grade, nameB))
+		}
+
+		if epA.Details == nil || epB.Details == nil {
+			continue
+		}
+
+		diffs = append(diffs, compareNames(epA.IPAddress, "protocol",
+			protocolNames(epA.Details), protocolNames(epB.Details), nameA, nameB)...)
+		diffs = append(diffs, compareNames(epA.IPAddress, "cipher suite",
+			suiteNames(epA.Details), suiteNames(epB.Details), nameA, nameB)...)
+
+		certA, certB := epA.Details.Cert, epB.Details.Cert
+		if certA.NotAfter != certB.NotAfter || (certA.SerialNumber != "" && certB.SerialNumber != "" && certA.SerialNumber != certB.SerialNumber) {
+			diffs = append(diffs, fmt.Sprintf("%s: different certificate (%s vs %s)",
+				epA.IPAddress, certA.Subject, certB.Subject))
+		}
+	}
+
+	for _, epB := range b.Endpoints {
+		if !slices.ContainsFunc(a.Endpoints, func(ep models.Endpoint) bool { return ep.IPAddress == epB.IPAddress }) {
+			diffs = append(diffs, fmt.Sprintf("%s: only reported by %s", epB.IPAddress, nameB))
+		}
+	}
+
+	return diffs
+}
+
+// compareNames reporta los elementos que solo aparecen en una de las listas
+func compareNames(ip, kind string, a, b []string, nameA, nameB string) []string {
+	var diffs []string
+	for _, name := range a {
+		if !slices.Contains(b, name) {
+			diffs = append(diffs, fmt.Sprintf("%s: %s %s only reported by %s", ip, kind, name, nameA))
+		}
+	}
+	for _, name := range b {
+		if !slices.Contains(a, name) {
+			diffs = append(diffs, fmt.Sprintf("%s: %s %s only reported by %s", ip, kind, name, nameB))
+		}
+	}
+	return diffs
+}
+
+func protocolNames(details *models.EndpointDetails) []string {
+	names := make([]string, 0, len(details.Protocols))
+	for _, p := range details.Protocols {
+		names = append(names, p.Name+" "+p.Version)
+	}
+	return names
+}
+
+func suiteNames(details *models.EndpointDetails) []string {
+	names := make([]string, 0, len(details.Suites.List))
+	for _, s := range details.Suites.List {
+		names = append(names, s.Name)
+	}
+	return names
+}
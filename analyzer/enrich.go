@@ -0,0 +1,73 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"NebulaChallenge/models"
+)
+
+// DefaultDetailConcurrency es el número de consultas getEndpointData
+// simultáneas por host
+const DefaultDetailConcurrency = 4
+
+// enrich completa los detalles de los endpoints que no los traen en el
+// resultado, consultando hasta detailConcurrency endpoints a la vez. Los
+// endpoints que no se pudieron completar quedan con DetailsError.
+func (a *Analyzer) enrich(ctx context.Context, req models.ScanRequest, result *models.Host) {
+	detailer, ok := optional[EndpointDetailer](a.scanner)
+	if !ok {
+		return
+	}
+
+	workers := a.detailConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	sem := make(chan struct{}, workers)
+
+	var wg sync.WaitGroup
+	for i := range result.Endpoints {
+		ep := &result.Endpoints[i]
+		if ep.Details != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			a.enrichEndpoint(ctx, detailer, req, ep)
+		}()
+	}
+	wg.Wait()
+}
+
+// enrichEndpoint obtiene los detalles de un endpoint y los copia en ep.
+// Cada goroutine escribe solo en su propio endpoint.
+func (a *Analyzer) enrichEndpoint(ctx context.Context, detailer EndpointDetailer, req models.ScanRequest, ep *models.Endpoint) {
+	if ctx.Err() != nil {
+		ep.DetailsError = ctx.Err().Error()
+		return
+	}
+
+	data, err := detailer.EndpointDetails(ctx, req, ep.IPAddress)
+	switch {
+	case err != nil:
+		ep.DetailsError = err.Error()
+	case data.Details == nil:
+		ep.DetailsError = fmt.Sprintf("no details returned (%s)", data.StatusMessage)
+	default:
+		ep.Details = data.Details
+		ep.DetailsError = ""
+	}
+
+	if ep.DetailsError != "" {
+		a.logger.Warn("could not fetch endpoint details",
+			"host", req.Target(), "endpoint", ep.IPAddress, "error", ep.DetailsError)
+	}
+}
@@ -0,0 +1,58 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"NebulaChallenge/models"
+)
+
+// FixtureScanner devuelve resultados grabados en lugar de analizar.
+// Lee <dir>/<host>.json, con el formato de formatter.ExportJSON.
+type FixtureScanner struct {
+	dir string
+}
+
+// NewFixtureScanner crea un backend que lee fixtures de dir
+func NewFixtureScanner(dir string) *FixtureScanner {
+	return &FixtureScanner{dir: dir}
+}
+
+// Name identifica al backend de fixtures
+func (f *FixtureScanner) Name() string {
+	return "fixture"
+}
+
+// Start carga el fixture del host; el resultado ya está completo
+func (f *FixtureScanner) Start(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	return f.load(req)
+}
+
+// Poll vuelve a cargar el fixture del host
+func (f *FixtureScanner) Poll(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	return f.load(req)
+}
+
+// Result devuelve el estado final, que ya es el fixture completo
+func (f *FixtureScanner) Result(ctx context.Context, req models.ScanRequest, status *models.Host) (*models.Host, error) {
+	return status, nil
+}
+
+func (f *FixtureScanner) load(req models.ScanRequest) (*models.Host, error) {
+	path := filepath.Join(f.dir, req.Host+".json")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading fixture: %w", err)
+	}
+
+	var host models.Host
+	if err := json.Unmarshal(data, &host); err != nil {
+		return nil, fmt.Errorf("error parsing fixture %s: %w", path, err)
+	}
+
+	return &host, nil
+}
@@ -0,0 +1,102 @@
+package analyzer
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"NebulaChallenge/client"
+	"NebulaChallenge/models"
+)
+
+// Option configura un Analyzer en NewAnalyzer
+type Option func(*Analyzer)
+
+// WithClient usa un cliente de SSL Labs ya configurado
+func WithClient(c *client.Client) Option {
+	return func(a *Analyzer) {
+		a.scanner = c
+	}
+}
+
+// WithScanner usa otro backend de análisis en lugar de SSL Labs
+func WithScanner(s Scanner) Option {
+	return func(a *Analyzer) {
+		a.scanner = s
+	}
+}
+
+// WithPollIntervals define la espera entre consultas mientras el análisis
+// está en cola (initial) y una vez que pasa a IN_PROGRESS (inProgress)
+func WithPollIntervals(initial, inProgress time.Duration) Option {
+	return func(a *Analyzer) {
+		a.pollInterval = initial
+		a.inProgressPollInterval = inProgress
+	}
+}
+
+// WithMaxWait limita el tiempo total de espera por host; al superarlo
+// Run devuelve el último resultado parcial y ErrMaxWaitExceeded
+func WithMaxWait(d time.Duration) Option {
+	return func(a *Analyzer) {
+		a.maxWait = d
+	}
+}
+
+// WithCache hace que el análisis use primero un reporte en caché de hasta
+// maxAgeHours horas (0 = cualquier antigüedad) si el backend lo permite
+func WithCache(maxAgeHours int) Option {
+	return func(a *Analyzer) {
+		a.fromCache = true
+		a.cacheMaxAge = maxAgeHours
+	}
+}
+
+// WithDetailConcurrency limita las consultas getEndpointData simultáneas
+// al completar los endpoints que llegan sin detalles
+func WithDetailConcurrency(n int) Option {
+	return func(a *Analyzer) {
+		a.detailConcurrency = n
+	}
+}
+
+// WithProgress recibe cada estado intermedio de Run en lugar de mostrarlo
+// en la terminal (p. ej. para exponer el progreso por HTTP)
+func WithProgress(fn func(status *models.Host)) Option {
+	return func(a *Analyzer) {
+		a.progress = fn
+	}
+}
+
+// WithOutput define dónde escribe Run sus mensajes y el progreso (por
+// defecto os.Stderr). io.Discard los desactiva, p. ej. cuando varios
+// análisis comparten el log de un servidor.
+func WithOutput(w io.Writer) Option {
+	return func(a *Analyzer) {
+		a.out = w
+	}
+}
+
+// WithHostDone recibe cada host de RunBatch en cuanto termina, antes de que
+// acabe el batch. Las llamadas no son concurrentes entre sí.
+func WithHostDone(fn func(entry *models.HostResult)) Option {
+	return func(a *Analyzer) {
+		a.hostDone = fn
+	}
+}
+
+// WithBatchStart recibe la descripción de cada batch de RunBatch antes de
+// encolar el primer host
+func WithBatchStart(fn func(start BatchStart)) Option {
+	return func(a *Analyzer) {
+		a.batchStart = fn
+	}
+}
+
+// WithLogger define el logger de eventos de polling. Si no se indica
+// WithClient ni WithScanner, el cliente creado por defecto también lo usa.
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *Analyzer) {
+		a.logger = logger
+	}
+}
@@ -0,0 +1,57 @@
+package analyzer
+
+import (
+	"context"
+
+	"NebulaChallenge/client"
+	"NebulaChallenge/models"
+)
+
+// Scanner es un backend de análisis TLS que el Analyzer puede orquestar:
+// SSL Labs (*client.Client), el scanner local (*scanner.Scanner), fixtures
+// grabados o una combinación de dos backends (CompositeScanner).
+type Scanner interface {
+	// Name identifica al backend en mensajes y comparaciones
+	Name() string
+	// Start inicia el análisis y devuelve el estado inicial
+	Start(ctx context.Context, req models.ScanRequest) (*models.Host, error)
+	// Poll consulta el estado actual de un análisis iniciado
+	Poll(ctx context.Context, req models.ScanRequest) (*models.Host, error)
+	// Result obtiene el resultado completo a partir del estado final
+	Result(ctx context.Context, req models.ScanRequest, status *models.Host) (*models.Host, error)
+}
+
+// InfoProvider lo implementan los backends que informan límites de
+// evaluaciones concurrentes (SSL Labs)
+type InfoProvider interface {
+	GetInfo(ctx context.Context) (*models.Info, error)
+	RateLimit() *client.RateLimitInfo
+}
+
+// CacheProvider lo implementan los backends que conservan reportes recientes
+// (SSL Labs). Cached devuelve el reporte en caché de hasta maxAge horas
+// (0 = cualquier antigüedad); si no hay uno, el estado devuelto puede
+// corresponder a una evaluación nueva ya iniciada por el backend.
+type CacheProvider interface {
+	Cached(ctx context.Context, req models.ScanRequest, maxAge int) (*models.Host, error)
+}
+
+// EndpointDetailer lo implementan los backends que pueden obtener los
+// detalles de un endpoint por separado (getEndpointData en SSL Labs)
+type EndpointDetailer interface {
+	EndpointDetails(ctx context.Context, req models.ScanRequest, ipAddress string) (*models.Endpoint, error)
+}
+
+// optional devuelve s como la interfaz opcional T (InfoProvider,
+// CacheProvider o EndpointDetailer). Un CompositeScanner solo la ofrece si
+// su backend principal la implementa.
+func optional[T any](s Scanner) (T, bool) {
+	if c, ok := s.(*CompositeScanner); ok {
+		if _, ok := c.primary.(T); !ok {
+			var zero T
+			return zero, false
+		}
+	}
+	t, ok := s.(T)
+	return t, ok
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"NebulaChallenge/client"
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+)
+
+// resultChecks agrupa las comprobaciones que se aplican a los resultados
+// terminados (--min-grade y --policy)
+type resultChecks struct {
+	grade  *gradeGate
+	policy *policyGate
+}
+
+// run evalúa los hosts y devuelve el código de salida correspondiente.
+// Un grado insuficiente tiene prioridad sobre una violación de política.
+func (c resultChecks) run(hosts ...*models.Host) int {
+	gradeFailed := c.grade.check(hosts...)
+	violated := c.policy.check(hosts...)
+
+	switch {
+	case gradeFailed:
+		return exitGradeFailure
+	case violated:
+		return exitPolicyViolation
+	default:
+		return exitOK
+	}
+}
+
+// errorExitCode traduce el error de un análisis a un código de salida
+func errorExitCode(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return exitCancelled
+	case client.IsRateLimited(err):
+		return exitRateLimited
+	default:
+		return exitError
+	}
+}
+
+// gradeGate compara el grado de cada endpoint con --min-grade
+type gradeGate struct {
+	minimum     string
+	ignoreTrust bool
+}
+
+// newGradeGate valida el grado mínimo; sin grado devuelve nil y no se evalúa nada
+func newGradeGate(minimum string, ignoreTrust bool) (*gradeGate, error) {
+	if minimum == "" {
+		return nil, nil
+	}
+	if !policy.ValidGrade(minimum) {
+		return nil, fmt.Errorf("invalid --min-grade %q (use A+, A, A-, B, C, D, E, F, T or M)", minimum)
+	}
+	return &gradeGate{minimum: minimum, ignoreTrust: ignoreTrust}, nil
+}
+
+// check informa en stderr los endpoints por debajo del mínimo e indica si hubo alguno
+func (g *gradeGate) check(hosts ...*models.Host) bool {
+	if g == nil {
+		return false
+	}
+
+	failed := false
+	for _, host := range hosts {
+		for _, failure := range policy.GradeFailures(host, g.minimum, g.ignoreTrust) {
+			fmt.Fprintf(os.Stderr, "Grade check failed: %s\n", failure)
+			failed = true
+		}
+	}
+	return failed
+}
+
+// policyGate evalúa los resultados contra la política de --policy
+type policyGate struct {
+	policy *policy.Policy
+	failOn policy.Severity
+	out    io.Writer
+}
+
+// newPolicyGate carga la política; sin archivo devuelve nil y no se evalúa nada
+func newPolicyGate(path, failOn string, out io.Writer) (*policyGate, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	severity, err := policy.ParseSeverity(failOn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --policy-fail-on: %w", err)
+	}
+
+	p, err := policy.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &policyGate{policy: p, failOn: severity, out: out}, nil
+}
+
+// check imprime el reporte de cada host e indica si alguno viola la política
+func (g *policyGate) check(hosts ...*models.Host) bool {
+	if g == nil {
+		return false
+	}
+
+	violated := false
+	for _, host := range hosts {
+		report := policy.Evaluate(g.policy, host)
+		formatter.PrintPolicyReport(g.out, report, g.failOn)
+		if len(report.Violations(g.failOn)) > 0 {
+			violated = true
+		}
+	}
+	return violated
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Esperas sugeridas por SSL Labs para los errores de sobrecarga
+const (
+	ServiceUnavailableWait = 15 * time.Minute
+	ServiceOverloadedWait  = 30 * time.Minute
+)
+
+// Errores centinela para comparar con errors.Is
+var (
+	ErrInvalidParameters  = errors.New("invalid parameters")
+	ErrRateLimited        = errors.New("rate limit exceeded")
+	ErrInternalServer     = errors.New("internal server error")
+	ErrServiceUnavailable = errors.New("service unavailable")
+	ErrServiceOverloaded  = errors.New("service overloaded")
+	ErrUnsupportedPort    = errors.New("SSL Labs only assesses port 443")
+)
+
+// APIError representa una respuesta de error de la API de SSL Labs
+type APIError struct {
+	StatusCode int
+	Body       string
+	// Retryable indica si tiene sentido reintentar la petición
+	Retryable bool
+	// RetryAfter es la espera sugerida antes de reintentar (0 si no hay sugerencia)
+	RetryAfter time.Duration
+}
+
+// Error implementa la interfaz error
+func (e *APIError) Error() string {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return fmt.Sprintf("invalid parameters (400): %s", e.Body)
+	case http.StatusTooManyRequests:
+		return "rate limit exceeded (429): too many requests"
+	case http.StatusInternalServerError:
+		return fmt.Sprintf("internal server error (500): %s", e.Body)
+	case http.StatusServiceUnavailable:
+		return "service unavailable (503): please retry in 15 minutes"
+	case 529:
+		return "service overloaded (529): please retry in 30 minutes"
+	default:
+		return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, e.Body)
+	}
+}
+
+// Is permite usar errors.Is con los errores centinela
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidParameters:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrInternalServer:
+		return e.StatusCode == http.StatusInternalServerError
+	case ErrServiceUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	case ErrServiceOverloaded:
+		return e.StatusCode == 529
+	}
+	return false
+}
+
+// newAPIError construye el error tipado para una respuesta no exitosa
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		apiErr.Retryable = true
+		apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	case http.StatusServiceUnavailable:
+		apiErr.Retryable = true
+		apiErr.RetryAfter = ServiceUnavailableWait
+	case 529:
+		apiErr.Retryable = true
+		apiErr.RetryAfter = ServiceOverloadedWait
+	}
+
+	return apiErr
+}
+
+// parseRetryAfter interpreta el header Retry-After en segundos
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// IsRetryable indica si err proviene de un error de la API que admite reintento
+func IsRetryable(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Retryable
+}
+
+// IsRateLimited indica si err se debe a que SSL Labs rechazó la petición por
+// límite de peticiones (429) o sobrecarga del servicio (529)
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited) || errors.Is(err, ErrServiceOverloaded)
+}
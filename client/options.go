@@ -0,0 +1,64 @@
+package client
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// Option configura un Client en NewClient
+type Option func(*Client)
+
+// WithBaseURL apunta el cliente a otra URL base, por ejemplo un mirror
+// interno o un servidor de pruebas
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient reemplaza el *http.Client usado para las peticiones
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTransport reemplaza el RoundTripper del *http.Client por defecto
+func WithTransport(transport http.RoundTripper) Option {
+	return func(c *Client) {
+		c.httpClient = &http.Client{
+			Timeout:   DefaultTimeout,
+			Transport: transport,
+		}
+	}
+}
+
+// WithUserAgent cambia el header User-Agent de las peticiones
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithRetryPolicy reemplaza la política de reintentos
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = policy
+	}
+}
+
+// WithAPIVersion selecciona la versión de la API. v4 exige el email con el
+// que se registró la organización en SSL Labs; ver APIVersion.Validate.
+func WithAPIVersion(version APIVersion, email string) Option {
+	return func(c *Client) {
+		c.version = version
+		c.email = email
+	}
+}
+
+// WithLogger define el logger para reintentos y esperas del rate limiting
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
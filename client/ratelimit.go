@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// assessmentSlotPollInterval es cada cuánto se vuelve a consultar /info
+// mientras se espera a que se libere un slot de evaluación
+const assessmentSlotPollInterval = 5 * time.Second
+
+// RateLimitInfo contiene información de rate limiting
+type RateLimitInfo struct {
+	MaxAssessments     int
+	CurrentAssessments int
+}
+
+// GetRateLimitInfo extrae información de rate limiting de los headers
+func (c *Client) GetRateLimitInfo(resp *http.Response) *RateLimitInfo {
+	maxStr := resp.Header.Get("X-Max-Assessments")
+	currentStr := resp.Header.Get("X-Current-Assessments")
+
+	info := &RateLimitInfo{}
+
+	if max, err := strconv.Atoi(maxStr); err == nil {
+		info.MaxAssessments = max
+	}
+
+	if current, err := strconv.Atoi(currentStr); err == nil {
+		info.CurrentAssessments = current
+	}
+
+	return info
+}
+
+// RateLimit devuelve la última información de rate limiting conocida,
+// o nil si la API todavía no la ha enviado
+func (c *Client) RateLimit() *RateLimitInfo {
+	return c.limiter.snapshot()
+}
+
+// recordRateLimit actualiza el governor con los headers de una respuesta
+func (c *Client) recordRateLimit(resp *http.Response) {
+	if resp.Header.Get("X-Max-Assessments") == "" {
+		return
+	}
+
+	info := c.GetRateLimitInfo(resp)
+	c.limiter.update(info.MaxAssessments, info.CurrentAssessments)
+}
+
+// waitForAssessmentSlot bloquea hasta que se pueda iniciar una nueva evaluación
+func (c *Client) waitForAssessmentSlot(ctx context.Context) error {
+	// El cool-off solo se conoce a través de /info
+	if !c.limiter.hasInfo() {
+		if _, err := c.GetInfo(ctx); err != nil {
+			return err
+		}
+	}
+
+	for {
+		wait, full := c.limiter.reserve(time.Now())
+		if wait == 0 {
+			return nil
+		}
+
+		c.logger.Info("waiting before starting a new assessment",
+			"wait", wait, "slotsFull", full)
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+
+		// Sin otras peticiones en curso nadie actualiza los headers,
+		// así que se consulta /info para conocer el estado actual
+		if full {
+			if _, err := c.GetInfo(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// governor lleva la cuenta de las evaluaciones en curso y del cool-off
+// entre evaluaciones nuevas que exige SSL Labs
+type governor struct {
+	mu sync.Mutex
+
+	known              bool
+	maxAssessments     int
+	currentAssessments int
+	reserved           int // reservas aún no confirmadas por la API
+
+	infoLoaded bool
+	coolOff    time.Duration
+	lastStart  time.Time
+}
+
+// update guarda los límites informados por la API
+func (g *governor) update(max, current int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.known = true
+	g.maxAssessments = max
+	g.currentAssessments = current
+	g.reserved = 0
+}
+
+// setInfo guarda los límites y el cool-off informados por /info
+func (g *governor) setInfo(info *models.Info) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.infoLoaded = true
+	g.coolOff = time.Duration(info.NewAssessmentCoolOff) * time.Millisecond
+	if info.MaxAssessments > 0 {
+		g.known = true
+		g.maxAssessments = info.MaxAssessments
+		g.currentAssessments = info.CurrentAssessments
+		g.reserved = 0
+	}
+}
+
+// hasInfo indica si ya se consultó /info
+func (g *governor) hasInfo() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.infoLoaded
+}
+
+// snapshot devuelve una copia de los límites conocidos
+func (g *governor) snapshot() *RateLimitInfo {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.known {
+		return nil
+	}
+	return &RateLimitInfo{
+		MaxAssessments:     g.maxAssessments,
+		CurrentAssessments: g.currentAssessments,
+	}
+}
+
+// reserve intenta reservar un slot para una evaluación nueva.
+// Devuelve 0 si la reserva se hizo, o cuánto esperar antes de reintentar;
+// full indica que la espera se debe a que no hay slots libres.
+func (g *governor) reserve(now time.Time) (wait time.Duration, full bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.known && g.maxAssessments > 0 && g.currentAssessments >= g.maxAssessments {
+		return assessmentSlotPollInterval, true
+	}
+
+	if !g.lastStart.IsZero() {
+		if elapsed := now.Sub(g.lastStart); elapsed < g.coolOff {
+			return g.coolOff - elapsed, false
+		}
+	}
+
+	g.lastStart = now
+	if g.known {
+		// Reserva optimista; la próxima respuesta trae el valor real
+		g.currentAssessments++
+		g.reserved++
+	}
+	return 0, false
+}
+
+// release devuelve un slot reservado cuando la evaluación no llegó a iniciarse
+func (g *governor) release() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.reserved > 0 {
+		g.reserved--
+		g.currentAssessments--
+	}
+}
+
+// sleepContext espera d o hasta que ctx se cancele
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
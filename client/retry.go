@@ -0,0 +1,81 @@
+package client
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy configura los reintentos de doRequest
+type RetryPolicy struct {
+	// MaxAttempts es el número total de intentos (1 desactiva los reintentos)
+	MaxAttempts int
+	// InitialBackoff es la espera antes del primer reintento
+	InitialBackoff time.Duration
+	// MaxBackoff limita el crecimiento exponencial de la espera
+	MaxBackoff time.Duration
+	// Multiplier es el factor de crecimiento entre reintentos
+	Multiplier float64
+	// Jitter es la fracción aleatoria (0-1) que se suma o resta a cada espera
+	Jitter float64
+	// MaxRetryAfter limita la espera sugerida por la API (15/30 min);
+	// 0 respeta siempre la sugerencia
+	MaxRetryAfter time.Duration
+}
+
+// DefaultMaxRetryAfter es el límite por defecto de la espera sugerida por la
+// API: sin él, 4 intentos tras un 503/529 pueden esperar más de una hora
+const DefaultMaxRetryAfter = time.Minute
+
+// DefaultRetryPolicy devuelve la política de reintentos por defecto
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    4,
+		InitialBackoff: 2 * time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2,
+		Jitter:         0.2,
+		MaxRetryAfter:  DefaultMaxRetryAfter,
+	}
+}
+
+// NoRetry devuelve una política que nunca reintenta
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// delay calcula la espera antes del reintento número attempt (desde 1).
+// Si la API sugirió una espera, se usa esa en lugar del backoff. También
+// lleva jitter, para que los workers de un batch no reintenten a la vez.
+func (p RetryPolicy) delay(attempt int, suggested time.Duration) time.Duration {
+	if suggested > 0 {
+		// La sugerencia es un mínimo: el jitter solo la alarga
+		wait := float64(suggested)
+		if p.Jitter > 0 {
+			wait += wait * p.Jitter * rand.Float64()
+		}
+
+		// Con el límite todos esperarían lo mismo: el jitter se resta de él
+		if p.MaxRetryAfter > 0 && wait > float64(p.MaxRetryAfter) {
+			wait = float64(p.MaxRetryAfter)
+			if p.Jitter > 0 {
+				wait -= wait * p.Jitter * rand.Float64()
+			}
+		}
+		return time.Duration(wait)
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= p.Multiplier
+		if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+			backoff = float64(p.MaxBackoff)
+			break
+		}
+	}
+
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(backoff)
+}
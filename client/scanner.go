@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"NebulaChallenge/models"
+)
+
+// Name identifica al backend SSL Labs
+func (c *Client) Name() string {
+	return "ssllabs"
+}
+
+// Start inicia una evaluación en SSL Labs. SSL Labs siempre analiza el
+// puerto 443: otro puerto es un error (ErrUnsupportedPort), para no
+// informar como host:puerto un análisis del 443.
+func (c *Client) Start(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	if err := checkPort(req); err != nil {
+		return nil, err
+	}
+	return c.StartAnalysis(ctx, req.Host, req.Publish)
+}
+
+// Poll consulta el estado de la evaluación
+func (c *Client) Poll(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	if err := checkPort(req); err != nil {
+		return nil, err
+	}
+	return c.CheckAnalysis(ctx, req.Host)
+}
+
+// Cached consulta la caché de SSL Labs. Si no hay un reporte de hasta maxAge
+// horas, SSL Labs inicia una evaluación nueva y devuelve su estado.
+func (c *Client) Cached(ctx context.Context, req models.ScanRequest, maxAge int) (*models.Host, error) {
+	if err := checkPort(req); err != nil {
+		return nil, err
+	}
+	return c.CheckAnalysisFromCache(ctx, req.Host, req.Publish, maxAge)
+}
+
+// Result devuelve el resultado final. Como las consultas usan all=done, el
+// último estado ya incluye todos los detalles y no hace falta otra petición.
+func (c *Client) Result(ctx context.Context, req models.ScanRequest, status *models.Host) (*models.Host, error) {
+	return status, nil
+}
+
+// EndpointDetails obtiene los detalles de un endpoint de una evaluación ya
+// terminada. Se pide la versión en caché para no iniciar otra evaluación.
+func (c *Client) EndpointDetails(ctx context.Context, req models.ScanRequest, ipAddress string) (*models.Endpoint, error) {
+	if err := checkPort(req); err != nil {
+		return nil, err
+	}
+	return c.GetEndpointData(ctx, req.Host, ipAddress, true)
+}
+
+// checkPort rechaza las peticiones a un puerto que SSL Labs no analiza
+func checkPort(req models.ScanRequest) error {
+	if req.Port != 0 && req.Port != 443 {
+		return fmt.Errorf("%w: cannot assess %s", ErrUnsupportedPort, req.Target())
+	}
+	return nil
+}
@@ -1,13 +1,16 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"NebulaChallenge/models"
@@ -18,60 +21,73 @@ const (
 	UserAgent = "Nebula-Challenge-SSLLabs-Client/1.0"
 )
 
+// DefaultTimeout es el timeout por petición del cliente HTTP por defecto
+const DefaultTimeout = 30 * time.Second
+
 // Client representa el cliente HTTP para SSL Labs API
 type Client struct {
 	httpClient *http.Client
 	baseURL    string
+	userAgent  string
+	logger     *slog.Logger
+
+	limiter governor
+	retry   RetryPolicy
+
+	version APIVersion
+	email   string // Requerido por v4
+
+	certsMu sync.Mutex
+	certs   map[string]models.V3Cert // Certificados v3/v4 por ID
 }
 
-// NewClient crea una nueva instancia del cliente
-func NewClient() *Client {
-	return &Client{
+// NewClient crea una nueva instancia del cliente.
+// Sin opciones usa la API pública v2 con un timeout de 30s por petición.
+func NewClient(opts ...Option) *Client {
+	c := &Client{
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: DefaultTimeout,
 		},
-		baseURL: BaseURL,
+		userAgent: UserAgent,
+		logger:    slog.New(slog.DiscardHandler),
+		retry:     DefaultRetryPolicy(),
+		version:   APIv2,
 	}
-}
 
-// RateLimitInfo contiene información de rate limiting
-type RateLimitInfo struct {
-	MaxAssessments     int
-	CurrentAssessments int
-}
-
-// GetRateLimitInfo extrae información de rate limiting de los headers
-func (c *Client) GetRateLimitInfo(resp *http.Response) *RateLimitInfo {
-	maxStr := resp.Header.Get("X-Max-Assessments")
-	currentStr := resp.Header.Get("X-Current-Assessments")
-
-	info := &RateLimitInfo{}
-
-	if max, err := strconv.Atoi(maxStr); err == nil {
-		info.MaxAssessments = max
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	if current, err := strconv.Atoi(currentStr); err == nil {
-		info.CurrentAssessments = current
+	// La URL base depende de la versión salvo que se haya indicado otra
+	if c.baseURL == "" {
+		c.baseURL = c.version.BaseURL()
 	}
 
-	return info
+	return c
 }
 
 // GetInfo obtiene información del servicio SSL Labs
-func (c *Client) GetInfo() (*models.Info, error) {
+func (c *Client) GetInfo(ctx context.Context) (*models.Info, error) {
 	endpoint := fmt.Sprintf("%s/info", c.baseURL)
 
 	var info models.Info
-	if err := c.doRequest(endpoint, &info); err != nil {
+	if err := c.doRequest(ctx, endpoint, &info); err != nil {
 		return nil, fmt.Errorf("error getting info: %w", err)
 	}
 
+	c.limiter.setInfo(&info)
+
 	return &info, nil
 }
 
-// StartAnalysis inicia un nuevo análisis
-func (c *Client) StartAnalysis(host string, publish bool) (*models.Host, error) {
+// StartAnalysis inicia un nuevo análisis.
+// Antes de enviar la petición espera el cool-off entre evaluaciones y,
+// si ya se alcanzó el máximo de evaluaciones concurrentes, a que se libere un slot.
+func (c *Client) StartAnalysis(ctx context.Context, host string, publish bool) (*models.Host, error) {
+	if err := c.waitForAssessmentSlot(ctx); err != nil {
+		return nil, fmt.Errorf("error waiting for assessment slot: %w", err)
+	}
+
 	params := url.Values{}
 	params.Add("host", host)
 	params.Add("startNew", "on")
@@ -85,32 +101,33 @@ func (c *Client) StartAnalysis(host string, publish bool) (*models.Host, error)
 
 	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, params.Encode())
 
-	var hostResult models.Host
-	if err := c.doRequest(endpoint, &hostResult); err != nil {
+	hostResult, err := c.decodeHost(ctx, endpoint)
+	if err != nil {
+		c.limiter.release()
 		return nil, fmt.Errorf("error starting analysis: %w", err)
 	}
 
-	return &hostResult, nil
+	return hostResult, nil
 }
 
 // CheckAnalysis verifica el estado del análisis
-func (c *Client) CheckAnalysis(host string) (*models.Host, error) {
+func (c *Client) CheckAnalysis(ctx context.Context, host string) (*models.Host, error) {
 	params := url.Values{}
 	params.Add("host", host)
 	params.Add("all", "done")
 
 	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, params.Encode())
 
-	var hostResult models.Host
-	if err := c.doRequest(endpoint, &hostResult); err != nil {
+	hostResult, err := c.decodeHost(ctx, endpoint)
+	if err != nil {
 		return nil, fmt.Errorf("error checking analysis: %w", err)
 	}
 
-	return &hostResult, nil
+	return hostResult, nil
 }
 
 // GetEndpointData obtiene información detallada de un endpoint específico
-func (c *Client) GetEndpointData(host string, ipAddress string, fromCache bool) (*models.Endpoint, error) {
+func (c *Client) GetEndpointData(ctx context.Context, host string, ipAddress string, fromCache bool) (*models.Endpoint, error) {
 	params := url.Values{}
 	params.Add("host", host)
 	params.Add("s", ipAddress) // ← 's' es el parámetro para la IP del endpoint
@@ -121,33 +138,46 @@ func (c *Client) GetEndpointData(host string, ipAddress string, fromCache bool)
 
 	endpoint := fmt.Sprintf("%s/getEndpointData?%s", c.baseURL, params.Encode())
 
-	var endpointResult models.Endpoint
-	if err := c.doRequest(endpoint, &endpointResult); err != nil {
+	endpointResult, err := c.decodeEndpoint(ctx, endpoint)
+	if err != nil {
 		return nil, fmt.Errorf("error getting endpoint data: %w", err)
 	}
 
-	return &endpointResult, nil
+	return endpointResult, nil
 }
 
-// CheckAnalysisFromCache obtiene resultados del cache si están disponibles
-func (c *Client) CheckAnalysisFromCache(host string, maxAge int) (*models.Host, error) {
+// CheckAnalysisFromCache obtiene resultados del cache si están disponibles.
+// Si no los hay SSL Labs inicia una evaluación nueva, así que antes se
+// reserva un slot igual que en StartAnalysis.
+func (c *Client) CheckAnalysisFromCache(ctx context.Context, host string, publish bool, maxAge int) (*models.Host, error) {
+	if err := c.waitForAssessmentSlot(ctx); err != nil {
+		return nil, fmt.Errorf("error waiting for assessment slot: %w", err)
+	}
+
 	params := url.Values{}
 	params.Add("host", host)
 	params.Add("fromCache", "on")
 	params.Add("all", "done")
 
+	if publish {
+		params.Add("publish", "on")
+	} else {
+		params.Add("publish", "off")
+	}
+
 	if maxAge > 0 {
 		params.Add("maxAge", strconv.Itoa(maxAge))
 	}
 
 	endpoint := fmt.Sprintf("%s/analyze?%s", c.baseURL, params.Encode())
 
-	var hostResult models.Host
-	if err := c.doRequest(endpoint, &hostResult); err != nil {
+	hostResult, err := c.decodeHost(ctx, endpoint)
+	if err != nil {
+		c.limiter.release()
 		return nil, fmt.Errorf("error checking cache: %w", err)
 	}
 
-	return &hostResult, nil
+	return hostResult, nil
 }
 
 // IsAnalysisComplete verifica si el análisis está completo
@@ -176,11 +206,13 @@ func GetStatusMessage(status string) string {
 	}
 }
 
-// IsServiceAvailable verifica si el servicio está disponible
-func (c *Client) IsServiceAvailable() (bool, error) {
-	_, err := c.GetInfo()
+// IsServiceAvailable verifica si el servicio está disponible.
+// Hace un único intento, sin aplicar la política de reintentos.
+func (c *Client) IsServiceAvailable(ctx context.Context) (bool, error) {
+	var info models.Info
+	_, err := c.doRequestOnce(ctx, fmt.Sprintf("%s/info", c.baseURL), &info)
 	if err != nil {
-		if strings.Contains(err.Error(), "503") || strings.Contains(err.Error(), "529") {
+		if errors.Is(err, ErrServiceUnavailable) || errors.Is(err, ErrServiceOverloaded) {
 			return false, nil
 		}
 		return false, err
@@ -188,54 +220,79 @@ func (c *Client) IsServiceAvailable() (bool, error) {
 	return true, nil
 }
 
-// doRequest realiza una petición HTTP GET y parsea la respuesta JSON
-func (c *Client) doRequest(endpoint string, result interface{}) error {
-	req, err := http.NewRequest("GET", endpoint, nil)
+// doRequest realiza una petición HTTP GET y parsea la respuesta JSON.
+// La petición se aborta en cuanto ctx se cancela. Los errores transitorios
+// (red, 429, 503, 529) se reintentan según la política de reintentos.
+func (c *Client) doRequest(ctx context.Context, endpoint string, result interface{}) error {
+	attempts := c.retry.MaxAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retryable bool
+		retryable, err = c.doRequestOnce(ctx, endpoint, result)
+		if err == nil || !retryable || attempt >= attempts {
+			return err
+		}
+
+		var suggested time.Duration
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			suggested = apiErr.RetryAfter
+		}
+
+		delay := c.retry.delay(attempt, suggested)
+		c.logger.Warn("retrying SSL Labs request",
+			"attempt", attempt, "maxAttempts", attempts, "delay", delay, "error", err)
+
+		if sleepErr := sleepContext(ctx, delay); sleepErr != nil {
+			return sleepErr
+		}
+	}
+}
+
+// doRequestOnce realiza un único intento de la petición e indica si
+// el error devuelto admite reintento
+func (c *Client) doRequestOnce(ctx context.Context, endpoint string, result interface{}) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
+		return false, fmt.Errorf("error creating request: %w", err)
 	}
 
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.version == APIv4 {
+		req.Header.Set("email", c.email)
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("error making request: %w", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		return true, fmt.Errorf("error making request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	c.recordRateLimit(resp)
+
 	// Leer el cuerpo de la respuesta
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading response body: %w", err)
+		return true, fmt.Errorf("error reading response body: %w", err)
 	}
 
 	// Verificar código de estado
 	if resp.StatusCode != http.StatusOK {
-		return c.handleErrorResponse(resp.StatusCode, body)
+		apiErr := newAPIError(resp, body)
+		return apiErr.Retryable, apiErr
 	}
 
 	// Parsear JSON
 	if err := json.Unmarshal(body, result); err != nil {
-		return fmt.Errorf("error parsing JSON: %w", err)
+		return false, fmt.Errorf("error parsing JSON: %w", err)
 	}
 
-	return nil
-}
-
-// handleErrorResponse maneja respuestas de error de la API
-func (c *Client) handleErrorResponse(statusCode int, body []byte) error {
-	switch statusCode {
-	case 400:
-		return fmt.Errorf("invalid parameters (400): %s", string(body))
-	case 429:
-		return fmt.Errorf("rate limit exceeded (429): too many requests")
-	case 500:
-		return fmt.Errorf("internal server error (500): %s", string(body))
-	case 503:
-		return fmt.Errorf("service unavailable (503): please retry in 15 minutes")
-	case 529:
-		return fmt.Errorf("service overloaded (529): please retry in 30 minutes")
-	default:
-		return fmt.Errorf("unexpected status code %d: %s", statusCode, string(body))
-	}
+	return false, nil
 }
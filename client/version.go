@@ -0,0 +1,116 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"NebulaChallenge/models"
+)
+
+// APIVersion identifica la versión de la API de SSL Labs
+type APIVersion int
+
+// Versiones soportadas de la API
+const (
+	APIv2 APIVersion = 2
+	APIv3 APIVersion = 3
+	APIv4 APIVersion = 4
+)
+
+// ParseAPIVersion convierte "2", "3", "4" (o "v2", "v3", "v4") en una APIVersion
+func ParseAPIVersion(s string) (APIVersion, error) {
+	switch s {
+	case "2", "v2":
+		return APIv2, nil
+	case "3", "v3":
+		return APIv3, nil
+	case "4", "v4":
+		return APIv4, nil
+	default:
+		return 0, fmt.Errorf("unsupported API version: %s (use 2, 3 or 4)", s)
+	}
+}
+
+// BaseURL devuelve la URL base pública de la versión
+func (v APIVersion) BaseURL() string {
+	return fmt.Sprintf("https://api.ssllabs.com/api/v%d", int(v))
+}
+
+// String implementa fmt.Stringer
+func (v APIVersion) String() string {
+	return fmt.Sprintf("v%d", int(v))
+}
+
+// Validate verifica que la versión sea soportada y que v4 tenga email
+func (v APIVersion) Validate(email string) error {
+	switch v {
+	case APIv2, APIv3:
+		return nil
+	case APIv4:
+		if email == "" {
+			return fmt.Errorf("API v4 requires a registered email")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported API version: %d", int(v))
+	}
+}
+
+// APIVersion devuelve la versión de la API que usa el cliente
+func (c *Client) APIVersion() APIVersion {
+	return c.version
+}
+
+// decodeHost obtiene un Host en el formato de la versión configurada y lo
+// normaliza al modelo interno
+func (c *Client) decodeHost(ctx context.Context, endpoint string) (*models.Host, error) {
+	if c.version == APIv2 {
+		var host models.Host
+		if err := c.doRequest(ctx, endpoint, &host); err != nil {
+			return nil, err
+		}
+		return &host, nil
+	}
+
+	var v3 models.V3Host
+	if err := c.doRequest(ctx, endpoint, &v3); err != nil {
+		return nil, err
+	}
+	c.rememberCerts(v3.Certs)
+	return models.NormalizeV3Host(&v3), nil
+}
+
+// decodeEndpoint obtiene un Endpoint en el formato de la versión configurada
+// y lo normaliza al modelo interno
+func (c *Client) decodeEndpoint(ctx context.Context, endpoint string) (*models.Endpoint, error) {
+	if c.version == APIv2 {
+		var ep models.Endpoint
+		if err := c.doRequest(ctx, endpoint, &ep); err != nil {
+			return nil, err
+		}
+		return &ep, nil
+	}
+
+	var v3 models.V3Endpoint
+	if err := c.doRequest(ctx, endpoint, &v3); err != nil {
+		return nil, err
+	}
+
+	c.certsMu.Lock()
+	defer c.certsMu.Unlock()
+	return models.NormalizeV3Endpoint(&v3, c.certs), nil
+}
+
+// rememberCerts guarda los certificados de host.certs para poder resolver
+// los certIds de respuestas posteriores de getEndpointData, que no los incluyen
+func (c *Client) rememberCerts(certs []models.V3Cert) {
+	c.certsMu.Lock()
+	defer c.certsMu.Unlock()
+
+	if c.certs == nil {
+		c.certs = make(map[string]models.V3Cert)
+	}
+	for _, cert := range certs {
+		c.certs[cert.ID] = cert
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"NebulaChallenge/diff"
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/models"
+)
+
+// runDiff implementa el comando "diff <old.json> <new.json>" y devuelve el
+// código de salida
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "Output changes as JSON")
+	if fs.Parse(args) != nil {
+		return exitError
+	}
+
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Usage: diff [--json] <old.json> <new.json>")
+		return exitError
+	}
+
+	older, err := loadHostFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	newer, err := loadHostFile(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	if older.Host != newer.Host {
+		fmt.Fprintf(os.Stderr, "Warning: comparing different hosts (%s and %s)\n", older.Host, newer.Host)
+	}
+
+	report := diff.Compare(older, newer)
+
+	if *asJSON {
+		jsonOutput, err := formatter.ExportDiffJSON(report)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error exporting JSON: %v\n", err)
+			return exitError
+		}
+		fmt.Println(jsonOutput)
+		return exitOK
+	}
+
+	formatter.PrintDiff(os.Stdout, report)
+	return exitOK
+}
+
+// loadHostFile lee un resultado guardado con --json o del historial
+func loadHostFile(path string) (*models.Host, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", path, err)
+	}
+
+	var host models.Host
+	if err := json.Unmarshal(data, &host); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+
+	return &host, nil
+}
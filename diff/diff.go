@@ -0,0 +1,194 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+)
+
+// Tipos de cambio
+const (
+	KindEndpoint       = "endpoint"
+	KindGrade          = "grade"
+	KindProtocol       = "protocol"
+	KindSuite          = "suite"
+	KindCertificate    = "certificate"
+	KindVulnerability  = "vulnerability"
+	KindForwardSecrecy = "forward_secrecy"
+	KindHSTS           = "hsts"
+)
+
+// Change es un cambio semántico en un endpoint entre dos evaluaciones
+type Change struct {
+	Endpoint string `json:"endpoint"`
+	Kind     string `json:"kind"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Report reúne los cambios entre dos evaluaciones del mismo host
+type Report struct {
+	Host        string   `json:"host"`
+	OldTestTime int64    `json:"oldTestTime,omitempty"`
+	NewTestTime int64    `json:"newTestTime,omitempty"`
+	Changes     []Change `json:"changes"`
+}
+
+// Compare describe los cambios de newer respecto de older, endpoint por
+// endpoint (emparejados por IP)
+func Compare(older, newer *models.Host) *Report {
+	report := &Report{
+		Host:        newer.Host,
+		OldTestTime: older.TestTime,
+		NewTestTime: newer.TestTime,
+		Changes:     []Change{},
+	}
+
+	for _, oldEp := range older.Endpoints {
+		idx := slices.IndexFunc(newer.Endpoints, func(ep models.Endpoint) bool {
+			return ep.IPAddress == oldEp.IPAddress
+		})
+		if idx < 0 {
+			report.add(oldEp.IPAddress, KindEndpoint, oldEp.Grade, "", "endpoint removed")
+			continue
+		}
+		report.compareEndpoint(&oldEp, &newer.Endpoints[idx])
+	}
+
+	for _, newEp := range newer.Endpoints {
+		if !slices.ContainsFunc(older.Endpoints, func(ep models.Endpoint) bool { return ep.IPAddress == newEp.IPAddress }) {
+			report.add(newEp.IPAddress, KindEndpoint, "", newEp.Grade, "endpoint added")
+		}
+	}
+
+	return report
+}
+
+func (r *Report) add(ip, kind, old, new, message string) {
+	r.Changes = append(r.Changes, Change{Endpoint: ip, Kind: kind, Old: old, New: new, Message: message})
+}
+
+func (r *Report) compareEndpoint(older, newer *models.Endpoint) {
+	ip := newer.IPAddress
+
+	if older.Grade != newer.Grade {
+		r.add(ip, KindGrade, older.Grade, newer.Grade,
+			fmt.Sprintf("grade %s -> %s%s", display(older.Grade), display(newer.Grade), gradeTrend(older.Grade, newer.Grade)))
+	}
+
+	// Sin detalles en alguna de las dos evaluaciones solo se compara el grado
+	if older.Details == nil || newer.Details == nil {
+		return
+	}
+	od, nd := older.Details, newer.Details
+
+	r.compareSets(ip, KindProtocol, "protocol", protocolNames(od), protocolNames(nd))
+	r.compareSets(ip, KindSuite, "cipher suite", suiteNames(od), suiteNames(nd))
+
+	oc, nc := od.Cert, nd.Cert
+	serialChanged := oc.SerialNumber != "" && nc.SerialNumber != "" && oc.SerialNumber != nc.SerialNumber
+	if serialChanged || oc.NotAfter != nc.NotAfter {
+		r.add(ip, KindCertificate, certSummary(oc), certSummary(nc),
+			fmt.Sprintf("new certificate: %s -> %s", certSummary(oc), certSummary(nc)))
+	}
+
+	for _, v := range models.Vulnerabilities {
+		was, is := v.Vulnerable(od), v.Vulnerable(nd)
+		switch {
+		case !was && is:
+			r.add(ip, KindVulnerability, "no", "yes", v.Name+": now vulnerable")
+		case was && !is:
+			r.add(ip, KindVulnerability, "yes", "no", v.Name+": no longer vulnerable")
+		}
+	}
+
+	if od.ForwardSecrecy != nd.ForwardSecrecy {
+		r.add(ip, KindForwardSecrecy, fmt.Sprint(od.ForwardSecrecy), fmt.Sprint(nd.ForwardSecrecy),
+			fmt.Sprintf("forward secrecy %d -> %d", od.ForwardSecrecy, nd.ForwardSecrecy))
+	}
+
+	if oh, nh := hstsSummary(od.HstsPolicy), hstsSummary(nd.HstsPolicy); oh != nh {
+		r.add(ip, KindHSTS, oh, nh, fmt.Sprintf("HSTS %s -> %s", oh, nh))
+	}
+}
+
+// compareSets reporta los elementos agregados y quitados entre dos listas
+func (r *Report) compareSets(ip, kind, label string, older, newer []string) {
+	for _, name := range newer {
+		if !slices.Contains(older, name) {
+			r.add(ip, kind, "", name, fmt.Sprintf("%s added: %s", label, name))
+		}
+	}
+	for _, name := range older {
+		if !slices.Contains(newer, name) {
+			r.add(ip, kind, name, "", fmt.Sprintf("%s removed: %s", label, name))
+		}
+	}
+}
+
+// gradeTrend indica si el grado mejoró o empeoró
+func gradeTrend(older, newer string) string {
+	oldRank, newRank := policy.GradeRank(older), policy.GradeRank(newer)
+	switch {
+	case oldRank < 0 || newRank < 0:
+		return ""
+	case newRank > oldRank:
+		return " (worse)"
+	default:
+		return " (better)"
+	}
+}
+
+func display(grade string) string {
+	if grade == "" {
+		return "none"
+	}
+	return grade
+}
+
+func certSummary(cert models.Cert) string {
+	expires := time.UnixMilli(cert.NotAfter).UTC().Format("2006-01-02")
+	if cert.SerialNumber == "" {
+		return "expires " + expires
+	}
+	return fmt.Sprintf("serial %s, expires %s", cert.SerialNumber, expires)
+}
+
+func hstsSummary(hsts *models.HstsPolicy) string {
+	if hsts == nil {
+		return "unknown"
+	}
+	if hsts.Status != "present" {
+		return hsts.Status
+	}
+
+	parts := []string{fmt.Sprintf("present max-age=%d", hsts.MaxAge)}
+	if hsts.IncludeSubDomains {
+		parts = append(parts, "includeSubDomains")
+	}
+	if hsts.Preload {
+		parts = append(parts, "preload")
+	}
+	return strings.Join(parts, " ")
+}
+
+func protocolNames(details *models.EndpointDetails) []string {
+	names := make([]string, 0, len(details.Protocols))
+	for _, p := range details.Protocols {
+		names = append(names, p.Name+" "+p.Version)
+	}
+	return names
+}
+
+func suiteNames(details *models.EndpointDetails) []string {
+	names := make([]string, 0, len(details.Suites.List))
+	for _, s := range details.Suites.List {
+		names = append(names, s.Name)
+	}
+	return names
+}
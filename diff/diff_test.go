@@ -0,0 +1,121 @@
+package diff
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+func TestCompare(t *testing.T) {
+	expires := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).UnixMilli()
+	details := func(fn func(d *models.EndpointDetails)) *models.EndpointDetails {
+		d := &models.EndpointDetails{
+			Cert:           models.Cert{SerialNumber: "01", NotAfter: expires},
+			Protocols:      []models.Protocol{{Name: "TLS", Version: "1.2"}},
+			Suites:         models.Suites{List: []models.Suite{{Name: "TLS_AES_128_GCM_SHA256"}}},
+			ForwardSecrecy: 4,
+			HstsPolicy:     &models.HstsPolicy{Status: "absent"},
+		}
+		if fn != nil {
+			fn(d)
+		}
+		return d
+	}
+
+	tests := []struct {
+		name         string
+		older, newer []models.Endpoint
+		want         []string
+	}{
+		{
+			name:  "no changes",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(nil)}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(nil)}},
+		},
+		{
+			name:  "endpoints added and removed",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A"}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.2", Grade: "B"}},
+			want:  []string{"endpoint removed", "endpoint added"},
+		},
+		{
+			name:  "grade worse",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A"}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "B"}},
+			want:  []string{"grade A -> B (worse)"},
+		},
+		{
+			name:  "grade better",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "T"}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A+"}},
+			want:  []string{"grade T -> A+ (better)"},
+		},
+		{
+			name:  "grade appears",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1"}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A"}},
+			want:  []string{"grade none -> A"},
+		},
+		{
+			name:  "details only in one evaluation",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A"}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(func(d *models.EndpointDetails) {
+				d.Heartbleed = true
+			})}},
+		},
+		{
+			name:  "protocols and suites",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(nil)}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(func(d *models.EndpointDetails) {
+				d.Protocols = []models.Protocol{{Name: "TLS", Version: "1.3"}}
+				d.Suites.List = append(d.Suites.List, models.Suite{Name: "TLS_AES_256_GCM_SHA384"})
+			})}},
+			want: []string{
+				"protocol added: TLS 1.3",
+				"protocol removed: TLS 1.2",
+				"cipher suite added: TLS_AES_256_GCM_SHA384",
+			},
+		},
+		{
+			name: "certificate, vulnerabilities, forward secrecy and HSTS",
+			older: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(func(d *models.EndpointDetails) {
+				d.Poodle = true
+			})}},
+			newer: []models.Endpoint{{IPAddress: "192.0.2.1", Grade: "A", Details: details(func(d *models.EndpointDetails) {
+				d.Cert.SerialNumber = "02"
+				d.Heartbleed = true
+				d.ForwardSecrecy = 2
+				d.HstsPolicy = &models.HstsPolicy{Status: "present", MaxAge: 31536000, IncludeSubDomains: true}
+			})}},
+			want: []string{
+				"new certificate: serial 01, expires 2025-06-01 -> serial 02, expires 2025-06-01",
+				"Heartbleed: now vulnerable",
+				"POODLE (SSL): no longer vulnerable",
+				"forward secrecy 4 -> 2",
+				"HSTS absent -> present max-age=31536000 includeSubDomains",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			older := &models.Host{Host: "example.com", TestTime: 1, Endpoints: tt.older}
+			newer := &models.Host{Host: "example.com", TestTime: 2, Endpoints: tt.newer}
+
+			report := Compare(older, newer)
+			if report.Host != "example.com" || report.OldTestTime != 1 || report.NewTestTime != 2 {
+				t.Errorf("unexpected report header: %+v", report)
+			}
+
+			var got []string
+			for _, c := range report.Changes {
+				got = append(got, c.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Compare() changes =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
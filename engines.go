@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"NebulaChallenge/analyzer"
+	"NebulaChallenge/client"
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/models"
+	"NebulaChallenge/scanner"
+	"NebulaChallenge/utils"
+)
+
+// engineConfig reúne los flags necesarios para construir los backends
+type engineConfig struct {
+	engine        string
+	apiVersion    string
+	email         string
+	baseURL       string
+	fixtures      string
+	compositeMode string
+	maxRetryAfter time.Duration
+	logger        *slog.Logger
+}
+
+// register define los flags de selección de backend en fs. Los usan tanto
+// el análisis normal como los comandos que analizan hosts (watch).
+func (cfg *engineConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&cfg.engine, "engine", "ssllabs", "Analysis backend: ssllabs, local, fixture or two of them separated by a comma")
+	fs.StringVar(&cfg.compositeMode, "composite-mode", "merge", "How to combine two engines: merge or compare")
+	fs.StringVar(&cfg.fixtures, "fixtures", "fixtures", "Directory with <host>.json files for the fixture engine")
+	fs.StringVar(&cfg.apiVersion, "api-version", "2", "SSL Labs API version (2, 3 or 4)")
+	fs.StringVar(&cfg.email, "email", os.Getenv("SSLLABS_EMAIL"), "Registered email for SSL Labs API v4")
+	fs.StringVar(&cfg.baseURL, "base-url", "", "SSL Labs API base URL (e.g. an internal mirror)")
+	fs.DurationVar(&cfg.maxRetryAfter, "max-retry-after", client.DefaultMaxRetryAfter, "Maximum wait before retrying when SSL Labs is overloaded (0 = the API suggestion, up to 30m)")
+}
+
+// build crea el backend indicado por --engine. Dos nombres separados por
+// coma crean un CompositeScanner.
+func (cfg engineConfig) build() (analyzer.Scanner, error) {
+	names := strings.Split(cfg.engine, ",")
+
+	switch len(names) {
+	case 1:
+		return cfg.single(strings.TrimSpace(names[0]))
+	case 2:
+		mode, err := analyzer.ParseCompositeMode(cfg.compositeMode)
+		if err != nil {
+			return nil, err
+		}
+
+		primary, err := cfg.single(strings.TrimSpace(names[0]))
+		if err != nil {
+			return nil, err
+		}
+		secondary, err := cfg.single(strings.TrimSpace(names[1]))
+		if err != nil {
+			return nil, err
+		}
+
+		return analyzer.NewCompositeScanner(primary, secondary, mode), nil
+	default:
+		return nil, fmt.Errorf("invalid engine %q: use one engine or two separated by a comma", cfg.engine)
+	}
+}
+
+// single crea un backend simple por nombre
+func (cfg engineConfig) single(name string) (analyzer.Scanner, error) {
+	switch name {
+	case "ssllabs":
+		apiVersion, err := client.ParseAPIVersion(cfg.apiVersion)
+		if err == nil {
+			err = apiVersion.Validate(cfg.email)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		retry := client.DefaultRetryPolicy()
+		retry.MaxRetryAfter = cfg.maxRetryAfter
+
+		opts := []client.Option{
+			client.WithAPIVersion(apiVersion, cfg.email),
+			client.WithLogger(cfg.logger),
+			client.WithRetryPolicy(retry),
+		}
+		if cfg.baseURL != "" {
+			opts = append(opts, client.WithBaseURL(cfg.baseURL))
+		}
+		return client.NewClient(opts...), nil
+	case "local":
+		return scanner.NewScanner(), nil
+	case "fixture":
+		return analyzer.NewFixtureScanner(cfg.fixtures), nil
+	default:
+		return nil, fmt.Errorf("unknown engine %q (use ssllabs, local or fixture)", name)
+	}
+}
+
+// printComparisons muestra las diferencias entre backends en modo compare
+func printComparisons(backend analyzer.Scanner, hosts []string) {
+	composite, ok := backend.(*analyzer.CompositeScanner)
+	if !ok {
+		return
+	}
+
+	for _, raw := range hosts {
+		host, port, err := utils.SplitHostPort(raw, 0)
+		if err != nil {
+			continue
+		}
+		target := models.ScanRequest{Host: host, Port: port}.Target()
+		if differences, ok := composite.Differences(target); ok {
+			formatter.PrintComparison(os.Stderr, target, differences)
+		}
+	}
+}
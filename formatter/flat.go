@@ -0,0 +1,131 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// csvProtocols son las versiones que tienen una columna propia en el CSV
+var csvProtocols = []string{"SSL 2.0", "SSL 3.0", "TLS 1.0", "TLS 1.1", "TLS 1.2", "TLS 1.3"}
+
+// csvHeader devuelve los nombres de columna: datos del endpoint, una columna
+// por protocolo (ssl_3_0, tls_1_2...), una por vulnerabilidad
+// (vuln_heartbleed...) y el error si el análisis del host falló
+func csvHeader() []string {
+	header := []string{
+		"host", "port", "ip_address", "status", "grade", "grade_trust_ignored", "test_time",
+		"key_alg", "key_size", "key_strength", "cert_not_after", "cert_days_left", "forward_secrecy",
+	}
+	for _, p := range csvProtocols {
+		header = append(header, strings.NewReplacer(" ", "_", ".", "_").Replace(strings.ToLower(p)))
+	}
+	for _, v := range models.Vulnerabilities {
+		header = append(header, "vuln_"+v.Key)
+	}
+	return append(header, "error")
+}
+
+// ExportCSV genera un CSV plano con una fila por host y endpoint. Las
+// columnas que dependen de los detalles quedan vacías si el endpoint no
+// los tiene. Un host que falló ocupa una fila con status ERROR y el error.
+func ExportCSV(batch *models.BatchResult) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write(csvHeader()); err != nil {
+		return "", fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	now := time.Now()
+	for _, entry := range batch.Results {
+		if !entry.Success() {
+			row := make([]string, len(csvHeader()))
+			row[0], row[3], row[len(row)-1] = entry.Host, "ERROR", entry.Error
+			if err := w.Write(row); err != nil {
+				return "", fmt.Errorf("error writing CSV: %w", err)
+			}
+			continue
+		}
+
+		host := entry.Result
+		for _, ep := range host.Endpoints {
+			if err := w.Write(csvRow(host, &ep, now)); err != nil {
+				return "", fmt.Errorf("error writing CSV: %w", err)
+			}
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("error writing CSV: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func csvRow(host *models.Host, ep *models.Endpoint, now time.Time) []string {
+	testTime := ""
+	if host.TestTime > 0 {
+		testTime = time.UnixMilli(host.TestTime).UTC().Format(time.RFC3339)
+	}
+
+	row := []string{
+		host.Host, strconv.Itoa(host.Port), ep.IPAddress, ep.StatusMessage,
+		ep.Grade, ep.GradeTrustIgnored, testTime,
+	}
+
+	d := ep.Details
+	if d == nil {
+		// Columnas de clave, certificado, protocolos, vulnerabilidades y
+		// error vacías
+		return append(row, make([]string, len(csvHeader())-len(row))...)
+	}
+
+	certNotAfter, certDaysLeft := "", ""
+	if d.Cert.NotAfter > 0 {
+		notAfter := time.UnixMilli(d.Cert.NotAfter)
+		certNotAfter = notAfter.UTC().Format(time.RFC3339)
+		certDaysLeft = strconv.Itoa(int(notAfter.Sub(now).Hours() / 24))
+	}
+
+	row = append(row,
+		d.Key.Alg, strconv.Itoa(d.Key.Size), strconv.Itoa(d.Key.Strength),
+		certNotAfter, certDaysLeft, strconv.Itoa(d.ForwardSecrecy),
+	)
+
+	for _, version := range csvProtocols {
+		supported := false
+		for _, p := range d.Protocols {
+			if p.Name+" "+p.Version == version {
+				supported = true
+				break
+			}
+		}
+		row = append(row, strconv.FormatBool(supported))
+	}
+	for _, v := range models.Vulnerabilities {
+		row = append(row, strconv.FormatBool(v.Vulnerable(d)))
+	}
+
+	return append(row, "")
+}
+
+// WriteNDJSON escribe el host en w como una línea de JSON compacto
+// (newline-delimited JSON), p. ej. para un recolector de logs
+func WriteNDJSON(w io.Writer, host *models.Host) error {
+	data, err := json.Marshal(host)
+	if err != nil {
+		return fmt.Errorf("error marshaling to JSON: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing NDJSON: %w", err)
+	}
+	return nil
+}
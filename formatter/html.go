@@ -0,0 +1,312 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// htmlFuncs son las funciones disponibles en la plantilla del reporte HTML
+var htmlFuncs = template.FuncMap{
+	"datetime": func(ms int64) string {
+		if ms <= 0 {
+			return "-"
+		}
+		return time.UnixMilli(ms).Format("2006-01-02 15:04:05 MST")
+	},
+	"date": func(ms int64) string {
+		if ms <= 0 {
+			return "-"
+		}
+		return time.UnixMilli(ms).Format("2006-01-02")
+	},
+	"daysLeft":       daysUntil,
+	"gradeClass":     gradeClass,
+	"forwardSecrecy": getForwardSecrecyStatus,
+	"certIssues": func(mask int) []string {
+		return models.ActiveIssues(mask, models.CertIssueFlags)
+	},
+	"chainIssues": func(mask int) []string {
+		return models.ActiveIssues(mask, models.ChainIssueFlags)
+	},
+	"vulnerabilities": func(d *models.EndpointDetails) []string {
+		var names []string
+		for _, v := range models.Vulnerabilities {
+			if v.Vulnerable(d) {
+				names = append(names, v.Name)
+			}
+		}
+		return names
+	},
+	"yesNo": func(b bool) string {
+		if b {
+			return "Yes"
+		}
+		return "No"
+	},
+	"join": strings.Join,
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(htmlFuncs).Parse(htmlTemplate))
+
+// gradeClass devuelve la clase CSS del grado
+func gradeClass(grade string) string {
+	switch grade {
+	case "A+", "A", "A-":
+		return "grade-a"
+	case "B":
+		return "grade-b"
+	case "C", "D", "E":
+		return "grade-c"
+	case "F", "T", "M":
+		return "grade-f"
+	default:
+		return "grade-none"
+	}
+}
+
+// ExportHTML genera un reporte HTML autocontenido (CSS embebido, sin
+// recursos externos) con un resumen de todos los hosts y una sección
+// desplegable por endpoint con todos sus detalles. Los hosts que fallaron
+// aparecen en el resumen y con su error.
+func ExportHTML(batch *models.BatchResult) (string, error) {
+	data := struct {
+		Generated time.Time
+		Hosts     []*models.Host
+	}{time.Now(), batch.Reports()}
+
+	var buf bytes.Buffer
+	if err := htmlReport.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error rendering HTML report: %w", err)
+	}
+	return buf.String(), nil
+}
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>SSL/TLS Security Assessment Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.3em; margin-top: 2em; border-bottom: 1px solid #ccc; }
+h3 { font-size: 1.05em; margin-bottom: .3em; }
+table { border-collapse: collapse; margin: .5em 0 1em; }
+th, td { border: 1px solid #ddd; padding: .3em .6em; text-align: left; vertical-align: top; font-size: .9em; }
+th { background: #f4f4f4; }
+td.num { text-align: right; }
+.grade { font-weight: bold; padding: .1em .5em; border-radius: 3px; color: #fff; }
+.grade-a { background: #2e7d32; }
+.grade-b { background: #f9a825; }
+.grade-c { background: #ef6c00; }
+.grade-f { background: #c62828; }
+.grade-none { background: #757575; }
+.bad { color: #c62828; font-weight: bold; }
+.ok { color: #2e7d32; }
+.muted { color: #757575; }
+details { border: 1px solid #ddd; border-radius: 4px; margin: .6em 0; padding: .4em .8em; }
+summary { cursor: pointer; font-weight: bold; }
+code { font-size: .9em; }
+</style>
+</head>
+<body>
+<h1>SSL/TLS Security Assessment Report</h1>
+<p class="muted">Generated {{.Generated.Format "2006-01-02 15:04:05 MST"}}</p>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Host</th><th>IP Address</th><th>Grade</th><th>Certificate Expiry</th><th>Days Left</th><th>Status</th></tr>
+{{- range .Hosts}}{{$host := .}}
+{{- range .Endpoints}}
+<tr>
+<td>{{$host.Host}}</td>
+<td>{{.IPAddress}}</td>
+<td><span class="grade {{gradeClass .Grade}}">{{or .Grade "-"}}</span></td>
+{{- if .Details}}
+<td>{{date .Details.Cert.NotAfter}}</td>
+{{- $days := daysLeft .Details.Cert.NotAfter}}
+<td class="num{{if lt $days 30}} bad{{end}}">{{$days}}</td>
+{{- else}}
+<td class="muted">-</td><td class="muted">-</td>
+{{- end}}
+<td>{{.StatusMessage}}</td>
+</tr>
+{{- else}}
+<tr><td>{{$host.Host}}</td><td colspan="5"{{if eq $host.Status "ERROR"}} class="bad"{{end}}>{{$host.Status}} {{$host.StatusMessage}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+
+{{- range .Hosts}}
+<h2>{{.Host}}</h2>
+{{- if eq .Status "ERROR"}}
+<p class="bad">Analysis failed: {{.StatusMessage}}</p>
+{{- end}}
+{{- if or .Endpoints (ne .Status "ERROR")}}
+<table>
+<tr><th>Port</th><td>{{.Port}}</td></tr>
+<tr><th>Protocol</th><td>{{.Protocol}}</td></tr>
+<tr><th>Status</th><td>{{.Status}}{{with .StatusMessage}} ({{.}}){{end}}</td></tr>
+<tr><th>Start Time</th><td>{{datetime .StartTime}}</td></tr>
+<tr><th>Test Time</th><td>{{datetime .TestTime}}</td></tr>
+{{- if .CacheExpiryTime}}
+<tr><th>Cache Expiry</th><td>{{datetime .CacheExpiryTime}}</td></tr>
+{{- end}}
+<tr><th>Engine Version</th><td>{{.EngineVersion}}</td></tr>
+<tr><th>Criteria Version</th><td>{{.CriteriaVersion}}</td></tr>
+{{- with .CertHostnames}}
+<tr><th>Certificate Hostnames</th><td>{{join . ", "}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+{{- range .Endpoints}}
+<details>
+<summary>{{.IPAddress}}{{with .ServerName}} ({{.}}){{end}} &mdash; <span class="grade {{gradeClass .Grade}}">{{or .Grade "-"}}</span></summary>
+
+<h3>Endpoint</h3>
+<table>
+<tr><th>Status</th><td>{{.StatusMessage}}{{with .StatusDetailsMessage}} ({{.}}){{end}}</td></tr>
+<tr><th>Grade</th><td>{{or .Grade "-"}}</td></tr>
+<tr><th>Grade (Trust Ignored)</th><td>{{or .GradeTrustIgnored "-"}}</td></tr>
+<tr><th>Has Warnings</th><td>{{yesNo .HasWarnings}}</td></tr>
+<tr><th>Exceptional</th><td>{{yesNo .IsExceptional}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}} ms</td></tr>
+<tr><th>Delegation</th><td>{{.Delegation}}</td></tr>
+</table>
+
+{{- if .Details}}{{with .Details}}{{$d := .}}
+<h3>Protocols</h3>
+<table>
+<tr><th>ID</th><th>Protocol</th><th>SSL 2.0 Suites Disabled</th></tr>
+{{- range .Protocols}}
+<tr><td><code>{{printf "0x%x" .ID}}</code></td><td>{{.Name}} {{.Version}}</td><td>{{yesNo .V2SuitesDisabled}}</td></tr>
+{{- end}}
+</table>
+
+<h3>Cipher Suites ({{len .Suites.List}}, server preference: {{yesNo .Suites.Preference}})</h3>
+<table>
+<tr><th>ID</th><th>Name</th><th>Strength</th><th>DH Strength</th><th>DH p / g / Ys</th><th>ECDH Bits</th><th>ECDH Strength</th></tr>
+{{- range .Suites.List}}
+<tr><td><code>{{printf "0x%x" .ID}}</code></td><td>{{.Name}}</td><td class="num">{{.CipherStrength}}</td>
+<td class="num">{{with .DhStrength}}{{.}}{{end}}</td><td>{{if .DhP}}{{.DhP}} / {{.DhG}} / {{.DhYs}}{{end}}</td>
+<td class="num">{{with .EcdhBits}}{{.}}{{end}}</td><td class="num">{{with .EcdhStrength}}{{.}}{{end}}</td></tr>
+{{- end}}
+</table>
+
+{{- with .Sims}}{{if .Results}}
+<h3>Handshake Simulation ({{len .Results}} clients, {{.Failed}} failed)</h3>
+<table>
+<tr><th>Client</th><th>Protocol</th><th>Cipher Suite</th><th>Key Exchange</th></tr>
+{{- range .Results}}
+<tr><td>{{.Client}}{{if .Client.IsReference}} <span class="muted">(reference)</span>{{end}}</td>
+{{- if .Connected}}
+<td>{{$d.ProtocolName .ProtocolID}}</td><td>{{$d.SimSuite .}}</td><td>{{if .KxInfo}}{{.KxInfo}}{{else if .KxType}}{{.KxType}} {{.KxStrength}}{{else}}-{{end}}</td></tr>
+{{- else}}
+<td colspan="3" class="bad">{{or .ErrorMessage "Handshake failed"}}</td></tr>
+{{- end}}
+{{- end}}
+</table>
+{{- end}}{{end}}
+
+<h3>Certificate</h3>
+<table>
+<tr><th>Subject</th><td>{{.Cert.Subject}}</td></tr>
+<tr><th>Serial Number</th><td><code>{{.Cert.SerialNumber}}</code></td></tr>
+<tr><th>Common Names</th><td>{{join .Cert.CommonNames ", "}}</td></tr>
+<tr><th>Alternative Names</th><td>{{join .Cert.AltNames ", "}}</td></tr>
+<tr><th>Valid From</th><td>{{datetime .Cert.NotBefore}}</td></tr>
+<tr><th>Valid Until</th><td>{{datetime .Cert.NotAfter}}</td></tr>
+<tr><th>Issuer</th><td>{{.Cert.IssuerLabel}}<br><span class="muted">{{.Cert.IssuerSubject}}</span></td></tr>
+<tr><th>Signature Algorithm</th><td>{{.Cert.SigAlg}}</td></tr>
+<tr><th>Validation Type</th><td>{{or .Cert.ValidationType "-"}}</td></tr>
+<tr><th>Revocation Info</th><td>{{.Cert.RevocationInfo}}</td></tr>
+<tr><th>Revocation Status</th><td>{{.Cert.RevocationStatus}} (CRL {{.Cert.CrlRevocationStatus}}, OCSP {{.Cert.OcspRevocationStatus}})</td></tr>
+<tr><th>CRL URIs</th><td>{{join .Cert.CrlURIs ", "}}</td></tr>
+<tr><th>OCSP URIs</th><td>{{join .Cert.OcspURIs ", "}}</td></tr>
+<tr><th>SGC</th><td>{{.Cert.Sgc}}</td></tr>
+<tr><th>SCT</th><td>{{yesNo .Cert.Sct}}</td></tr>
+<tr><th>Issues</th><td>{{with certIssues .Cert.Issues}}<span class="bad">{{join . ", "}}</span>{{else}}<span class="ok">None</span>{{end}}</td></tr>
+</table>
+
+<h3>Key</h3>
+<table>
+<tr><th>Algorithm</th><td>{{.Key.Alg}}</td></tr>
+<tr><th>Size</th><td>{{.Key.Size}} bits</td></tr>
+<tr><th>Strength</th><td>{{.Key.Strength}} bits</td></tr>
+<tr><th>Debian Flaw</th><td>{{yesNo .Key.DebianFlaw}}</td></tr>
+</table>
+
+<h3>Certificate Chain</h3>
+<p>Issues: {{with chainIssues .Chain.Issues}}<span class="bad">{{join . ", "}}</span>{{else}}<span class="ok">None</span>{{end}}</p>
+<table>
+<tr><th>#</th><th>Subject</th><th>Issuer</th><th>Valid Until</th><th>Key</th><th>Signature</th><th>Issues</th><th>Revocation</th></tr>
+{{- range $i, $c := .Chain.Certs}}
+<tr><td class="num">{{$i}}</td><td>{{$c.Label}}<br><span class="muted">{{$c.Subject}}</span></td>
+<td>{{$c.IssuerLabel}}</td><td>{{date $c.NotAfter}}</td>
+<td>{{$c.KeyAlg}} {{$c.KeySize}} ({{$c.KeyStrength}})</td><td>{{$c.SigAlg}}</td>
+<td>{{with certIssues $c.Issues}}<span class="bad">{{join . ", "}}</span>{{else}}-{{end}}</td>
+<td>{{$c.RevocationStatus}} (CRL {{$c.CrlRevocationStatus}}, OCSP {{$c.OcspRevocationStatus}})</td></tr>
+{{- end}}
+</table>
+
+<h3>Vulnerabilities</h3>
+<table>
+<tr><th>Vulnerable To</th><td>{{with vulnerabilities .}}<span class="bad">{{join . ", "}}</span>{{else}}<span class="ok">No major vulnerabilities detected</span>{{end}}</td></tr>
+<tr><th>POODLE (TLS)</th><td>{{.PoodleTls}}</td></tr>
+<tr><th>OpenSSL CCS</th><td>{{.OpenSslCcs}}</td></tr>
+<tr><th>Heartbeat</th><td>{{yesNo .Heartbeat}}</td></tr>
+<tr><th>Forward Secrecy</th><td>{{forwardSecrecy .ForwardSecrecy}}</td></tr>
+<tr><th>RC4</th><td>supported: {{yesNo .SupportsRc4}}, with modern: {{yesNo .Rc4WithModern}}, only: {{yesNo .Rc4Only}}</td></tr>
+<tr><th>Fallback SCSV</th><td>{{yesNo .FallbackScsv}}</td></tr>
+<tr><th>DH Known Primes</th><td>{{.DhUsesKnownPrimes}}</td></tr>
+<tr><th>DH Ys Reuse</th><td>{{yesNo .DhYsReuse}}</td></tr>
+<tr><th>DH Primes</th><td>{{range .DhPrimes}}<code>{{.}}</code><br>{{else}}-{{end}}</td></tr>
+</table>
+
+<h3>Features</h3>
+<table>
+<tr><th>Renegotiation Support</th><td>{{.RenegSupport}}</td></tr>
+<tr><th>Session Resumption</th><td>{{.SessionResumption}}</td></tr>
+<tr><th>Session Tickets</th><td>{{.SessionTickets}}</td></tr>
+<tr><th>Compression Methods</th><td>{{.CompressionMethods}}</td></tr>
+<tr><th>NPN</th><td>{{yesNo .SupportsNpn}}{{with .NpnProtocols}} ({{.}}){{end}}</td></tr>
+<tr><th>OCSP Stapling</th><td>{{yesNo .OcspStapling}}{{if .OcspStapling}} (revocation status {{.StaplingRevocationStatus}}){{end}}</td></tr>
+<tr><th>SNI Required</th><td>{{yesNo .SniRequired}}</td></tr>
+<tr><th>SCT</th><td>{{.HasSct}}</td></tr>
+<tr><th>ChaCha20 Preference</th><td>{{yesNo .ChaCha20Preference}}</td></tr>
+<tr><th>Server Signature</th><td>{{or .ServerSignature "-"}}</td></tr>
+<tr><th>Prefix Delegation</th><td>{{yesNo .PrefixDelegation}} (non-prefix: {{yesNo .NonPrefixDelegation}})</td></tr>
+<tr><th>Host Start Time</th><td>{{datetime .HostStartTime}}</td></tr>
+</table>
+
+<h3>HTTP</h3>
+<table>
+<tr><th>Status Code</th><td>{{or .HTTPStatusCode "-"}}</td></tr>
+<tr><th>Forwarding</th><td>{{or .HTTPForwarding "-"}}</td></tr>
+{{- with .HstsPolicy}}
+<tr><th>HSTS</th><td>{{.Status}}{{with .Error}} ({{.}}){{end}}</td></tr>
+<tr><th>HSTS Max Age</th><td>{{.MaxAge}} s (long: {{.LongMaxAge}} s)</td></tr>
+<tr><th>HSTS Include Subdomains</th><td>{{yesNo .IncludeSubDomains}}</td></tr>
+<tr><th>HSTS Preload</th><td>{{yesNo .Preload}}</td></tr>
+<tr><th>HSTS Header</th><td><code>{{.Header}}</code></td></tr>
+{{- else}}
+<tr><th>HSTS</th><td class="muted">unknown</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- else}}
+<p class="muted">Details unavailable{{with .DetailsError}} ({{.}}){{end}}</p>
+{{- end}}
+</details>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`
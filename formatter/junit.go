@@ -0,0 +1,243 @@
+package formatter
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+)
+
+// DefaultJUnitMinGrade es el grado mínimo del caso "grade" cuando no se
+// indica otro: solo fallan F, T y M
+const DefaultJUnitMinGrade = "E"
+
+// junitVulnerabilities son las claves de models.Vulnerabilities que tienen
+// un testcase propio, en el orden del reporte de texto
+var junitVulnerabilities = []string{"beast", "heartbleed", "poodle", "poodle_tls", "freak", "logjam", "rc4_only"}
+
+// JUnitOptions ajusta las comprobaciones del reporte JUnit
+type JUnitOptions struct {
+	MinGrade    string // Grado mínimo del caso "grade" (por defecto DefaultJUnitMinGrade)
+	IgnoreTrust bool   // Comparar GradeTrustIgnored en lugar de Grade
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     float64          `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      float64         `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      float64       `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// ExportJUnit convierte el batch en un reporte JUnit XML: un testsuite por
+// host y un testcase por endpoint y comprobación (grado, cada vulnerabilidad
+// de junitVulnerabilities, validez del certificado y forward secrecy). Los
+// fallos incluyen los valores que los causan. Un host que no se pudo
+// analizar tiene un único testcase "analysis" con el error.
+func ExportJUnit(batch *models.BatchResult, opts JUnitOptions) (string, error) {
+	if opts.MinGrade == "" {
+		opts.MinGrade = DefaultJUnitMinGrade
+	}
+
+	report := junitTestSuites{Name: "nebula-challenge"}
+	now := time.Now()
+	for _, entry := range batch.Results {
+		suite := junitErrorSuite(&entry)
+		if entry.Success() {
+			suite = junitHostSuite(entry.Result, opts, now)
+		}
+		report.Suites = append(report.Suites, suite)
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+		report.Time += suite.Time
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling to JUnit XML: %w", err)
+	}
+	return xml.Header + string(data), nil
+}
+
+// junitErrorSuite es el testsuite de un host cuyo análisis falló
+func junitErrorSuite(entry *models.HostResult) junitTestSuite {
+	return junitTestSuite{
+		Name:   entry.Host,
+		Tests:  1,
+		Errors: 1,
+		Cases: []junitTestCase{{
+			Name:      "analysis",
+			ClassName: entry.Host,
+			Error:     &junitProblem{Message: entry.Error, Type: "analysis", Text: entry.Error},
+		}},
+	}
+}
+
+func junitHostSuite(host *models.Host, opts JUnitOptions, now time.Time) junitTestSuite {
+	suite := junitTestSuite{Name: host.Host}
+	if host.TestTime > 0 {
+		suite.Timestamp = time.UnixMilli(host.TestTime).UTC().Format("2006-01-02T15:04:05")
+	}
+
+	for _, ep := range host.Endpoints {
+		suite.Time += float64(ep.Duration) / 1000
+		for _, tc := range junitEndpointCases(host, &ep, opts, now) {
+			suite.Tests++
+			switch {
+			case tc.Failure != nil:
+				suite.Failures++
+			case tc.Error != nil:
+				suite.Errors++
+			case tc.Skipped != nil:
+				suite.Skipped++
+			}
+			suite.Cases = append(suite.Cases, tc)
+		}
+	}
+	return suite
+}
+
+func junitEndpointCases(host *models.Host, ep *models.Endpoint, opts JUnitOptions, now time.Time) []junitTestCase {
+	className := host.Host + "." + ep.IPAddress
+	newCase := func(name string) junitTestCase {
+		return junitTestCase{Name: name, ClassName: className}
+	}
+
+	grade := newCase("grade")
+	value := ep.Grade
+	if opts.IgnoreTrust && ep.GradeTrustIgnored != "" {
+		value = ep.GradeTrustIgnored
+	}
+	switch {
+	case value == "":
+		grade.Error = &junitProblem{Message: "no grade", Type: "grade",
+			Text: fmt.Sprintf("status: %s", ep.StatusMessage)}
+	case !policy.MeetsGrade(value, opts.MinGrade):
+		grade.Failure = &junitProblem{Message: fmt.Sprintf("grade %s is below %s", value, opts.MinGrade), Type: "grade",
+			Text: fmt.Sprintf("grade: %s\ngradeTrustIgnored: %s\nminimum: %s", ep.Grade, ep.GradeTrustIgnored, opts.MinGrade)}
+	}
+	cases := []junitTestCase{grade}
+
+	names := make([]string, 0, len(junitVulnerabilities)+2)
+	for _, key := range junitVulnerabilities {
+		names = append(names, "vulnerability/"+key)
+	}
+	names = append(names, "certificate", "forward_secrecy")
+
+	// Sin detalles el resto de comprobaciones no se puede evaluar
+	if ep.Details == nil {
+		reason := "endpoint details unavailable"
+		if ep.DetailsError != "" {
+			reason += ": " + ep.DetailsError
+		}
+		for _, name := range names {
+			tc := newCase(name)
+			tc.Skipped = &junitSkipped{Message: reason}
+			cases = append(cases, tc)
+		}
+		return cases
+	}
+
+	d := ep.Details
+	for _, key := range junitVulnerabilities {
+		tc := newCase("vulnerability/" + key)
+		for _, v := range models.Vulnerabilities {
+			if v.Key == key && v.Vulnerable(d) {
+				tc.Failure = &junitProblem{Message: v.Name + ": Vulnerable", Type: "vulnerability",
+					Text: vulnerabilityValue(key, d)}
+			}
+		}
+		cases = append(cases, tc)
+	}
+
+	cert := newCase("certificate")
+	if problems := certProblems(&d.Cert, now); len(problems) > 0 {
+		cert.Failure = &junitProblem{Message: strings.Join(problems, "; "), Type: "certificate",
+			Text: fmt.Sprintf("subject: %s\nissuer: %s\nnotBefore: %s\nnotAfter: %s\nissues: %d",
+				d.Cert.Subject, d.Cert.IssuerLabel,
+				time.UnixMilli(d.Cert.NotBefore).UTC().Format(time.RFC3339),
+				time.UnixMilli(d.Cert.NotAfter).UTC().Format(time.RFC3339), d.Cert.Issues)}
+	}
+	cases = append(cases, cert)
+
+	fs := newCase("forward_secrecy")
+	if d.ForwardSecrecy&(2|4) == 0 {
+		fs.Failure = &junitProblem{Message: "forward secrecy: " + getForwardSecrecyStatus(d.ForwardSecrecy), Type: "forward_secrecy",
+			Text: fmt.Sprintf("forwardSecrecy: %d", d.ForwardSecrecy)}
+	}
+	return append(cases, fs)
+}
+
+// vulnerabilityValue muestra el campo de EndpointDetails que marca la
+// vulnerabilidad, con su valor
+func vulnerabilityValue(key string, d *models.EndpointDetails) string {
+	switch key {
+	case "beast":
+		return fmt.Sprintf("vulnBeast: %t", d.VulnBeast)
+	case "heartbleed":
+		return fmt.Sprintf("heartbleed: %t", d.Heartbleed)
+	case "poodle":
+		return fmt.Sprintf("poodle: %t", d.Poodle)
+	case "poodle_tls":
+		return fmt.Sprintf("poodleTls: %d", d.PoodleTls)
+	case "freak":
+		return fmt.Sprintf("freak: %t", d.Freak)
+	case "logjam":
+		return fmt.Sprintf("logjam: %t", d.Logjam)
+	case "rc4_only":
+		return fmt.Sprintf("rc4Only: %t", d.Rc4Only)
+	default:
+		return ""
+	}
+}
+
+// certProblems explica por qué el certificado no es válido ahora: fechas
+// fuera de rango y bits activos de Cert.Issues
+func certProblems(cert *models.Cert, now time.Time) []string {
+	var problems []string
+	if cert.NotBefore > 0 && now.Before(time.UnixMilli(cert.NotBefore)) {
+		problems = append(problems, "not valid before "+time.UnixMilli(cert.NotBefore).UTC().Format("2006-01-02"))
+	}
+	if cert.NotAfter > 0 && now.After(time.UnixMilli(cert.NotAfter)) {
+		problems = append(problems, "expired on "+time.UnixMilli(cert.NotAfter).UTC().Format("2006-01-02"))
+	}
+	return append(problems, models.ActiveIssues(cert.Issues, models.CertIssueFlags)...)
+}
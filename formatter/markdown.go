@@ -0,0 +1,151 @@
+package formatter
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// ExportMarkdown genera un reporte en Markdown (GitHub Flavored) pensado
+// para comentarios de pull requests y wikis: una tabla resumen de endpoints
+// y grados y, por endpoint, protocolos, certificado, la lista completa de
+// cipher suites y vulnerabilidades. Los hosts con varios endpoints usan un
+// bloque <details> desplegable por IP. Los hosts que fallaron aparecen en el
+// resumen y con su error.
+func ExportMarkdown(batch *models.BatchResult) (string, error) {
+	var b strings.Builder
+	hosts := batch.Reports()
+
+	b.WriteString("# SSL/TLS Security Assessment Report\n\n")
+	b.WriteString("| Host | IP Address | Grade | Certificate Expiry | Status |\n")
+	b.WriteString("|------|------------|-------|--------------------|--------|\n")
+	for _, host := range hosts {
+		if len(host.Endpoints) == 0 {
+			fmt.Fprintf(&b, "| %s | - | - | - | %s |\n", mdCell(host.Host), mdCell(host.Status+" "+host.StatusMessage))
+			continue
+		}
+		for _, ep := range host.Endpoints {
+			expiry := "-"
+			if ep.Details != nil {
+				expiry = mdDate(ep.Details.Cert.NotAfter)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", mdCell(host.Host), ep.IPAddress,
+				mdGrade(ep.Grade), expiry, mdCell(ep.StatusMessage))
+		}
+	}
+
+	for _, host := range hosts {
+		fmt.Fprintf(&b, "\n## %s\n", host.Host)
+		if host.Status == "ERROR" {
+			fmt.Fprintf(&b, "\n**Analysis failed:** %s\n", host.StatusMessage)
+		}
+		if host.TestTime > 0 {
+			fmt.Fprintf(&b, "\nTested %s", time.UnixMilli(host.TestTime).Format("2006-01-02 15:04:05 MST"))
+			if host.EngineVersion != "" {
+				fmt.Fprintf(&b, " with engine %s", host.EngineVersion)
+			}
+			b.WriteString(".\n")
+		}
+
+		// Dentro de <summary> no se interpreta Markdown
+		multi := len(host.Endpoints) > 1
+		for _, ep := range host.Endpoints {
+			if multi {
+				fmt.Fprintf(&b, "\n<details>\n<summary>%s: <b>%s</b></summary>\n", ep.IPAddress, cmp.Or(ep.Grade, "-"))
+			} else {
+				fmt.Fprintf(&b, "\n### %s: %s\n", ep.IPAddress, mdGrade(ep.Grade))
+			}
+
+			writeMarkdownEndpoint(&b, &ep)
+
+			if multi {
+				b.WriteString("\n</details>\n")
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+func writeMarkdownEndpoint(b *strings.Builder, ep *models.Endpoint) {
+	if ep.Details == nil {
+		if ep.DetailsError != "" {
+			fmt.Fprintf(b, "\nDetails unavailable: %s\n", ep.DetailsError)
+		} else {
+			b.WriteString("\nDetails unavailable.\n")
+		}
+		return
+	}
+	d := ep.Details
+
+	b.WriteString("\n#### Protocols\n\n")
+	for _, p := range d.Protocols {
+		fmt.Fprintf(b, "- %s %s\n", p.Name, p.Version)
+	}
+
+	b.WriteString("\n#### Certificate\n\n")
+	b.WriteString("| Field | Value |\n|-------|-------|\n")
+	fmt.Fprintf(b, "| Subject | %s |\n", mdCell(d.Cert.Subject))
+	fmt.Fprintf(b, "| Issuer | %s |\n", mdCell(d.Cert.IssuerLabel))
+	if len(d.Cert.AltNames) > 0 {
+		fmt.Fprintf(b, "| Alternative Names | %s |\n", mdCell(strings.Join(d.Cert.AltNames, ", ")))
+	}
+	fmt.Fprintf(b, "| Valid From | %s |\n", mdDate(d.Cert.NotBefore))
+	fmt.Fprintf(b, "| Valid Until | %s |\n", mdDate(d.Cert.NotAfter))
+	fmt.Fprintf(b, "| Key | %s %d bits (strength %d) |\n", d.Key.Alg, d.Key.Size, d.Key.Strength)
+	if d.Cert.SigAlg != "" {
+		fmt.Fprintf(b, "| Signature | %s |\n", d.Cert.SigAlg)
+	}
+	if issues := models.ActiveIssues(d.Cert.Issues, models.CertIssueFlags); len(issues) > 0 {
+		fmt.Fprintf(b, "| Issues | **%s** |\n", mdCell(strings.Join(issues, ", ")))
+	}
+	if issues := models.ActiveIssues(d.Chain.Issues, models.ChainIssueFlags); len(issues) > 0 {
+		fmt.Fprintf(b, "| Chain Issues | **%s** |\n", mdCell(strings.Join(issues, ", ")))
+	}
+
+	fmt.Fprintf(b, "\n#### Cipher Suites (%d)\n\n", len(d.Suites.List))
+	if len(d.Suites.List) > 0 {
+		b.WriteString("| Suite | Strength |\n|-------|---------:|\n")
+		for _, s := range d.Suites.List {
+			fmt.Fprintf(b, "| `%s` | %d |\n", s.Name, s.CipherStrength)
+		}
+	}
+
+	b.WriteString("\n#### Vulnerabilities\n\n")
+	vulnerable := false
+	for _, v := range models.Vulnerabilities {
+		if v.Vulnerable(d) {
+			fmt.Fprintf(b, "- **%s**: vulnerable\n", v.Name)
+			vulnerable = true
+		}
+	}
+	if !vulnerable {
+		b.WriteString("- No major vulnerabilities detected\n")
+	}
+	fmt.Fprintf(b, "- Forward Secrecy: %s\n", getForwardSecrecyStatus(d.ForwardSecrecy))
+}
+
+// mdGrade muestra el grado en negrita, o "-" si no hay
+func mdGrade(grade string) string {
+	if grade == "" {
+		return "-"
+	}
+	return "**" + grade + "**"
+}
+
+// mdDate formatea una fecha en milisegundos, o "-" si no hay
+func mdDate(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Format("2006-01-02")
+}
+
+// mdCell escapa el texto para usarlo dentro de una celda de tabla
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(strings.TrimSpace(s), "\n", " ")
+}
@@ -3,195 +3,179 @@ package formatter
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
+	"NebulaChallenge/diff"
 	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+	"NebulaChallenge/storage"
 )
 
-// PrintReport imprime el reporte de forma legible
-func PrintReport(host *models.Host) {
-	fmt.Println("\n" + strings.Repeat("=", 80))
-	fmt.Printf("SSL/TLS SECURITY ASSESSMENT REPORT\n")
-	fmt.Println(strings.Repeat("=", 80))
-
-	fmt.Printf("\nHost: %s\n", host.Host)
-	fmt.Printf("Port: %d\n", host.Port)
-	fmt.Printf("Protocol: %s\n", host.Protocol)
-	fmt.Printf("Status: %s\n", host.Status)
-
-	if host.TestTime > 0 {
-		testTime := time.Unix(host.TestTime/1000, 0)
-		fmt.Printf("Test Time: %s\n", testTime.Format("2006-01-02 15:04:05 MST"))
-	}
-
-	fmt.Printf("\nEngine Version: %s\n", host.EngineVersion)
-	fmt.Printf("Criteria Version: %s\n", host.CriteriaVersion)
-
-	// Endpoints
-	fmt.Printf("\n%s\n", strings.Repeat("-", 80))
-	fmt.Printf("ENDPOINTS (%d)\n", len(host.Endpoints))
-	fmt.Printf("%s\n", strings.Repeat("-", 80))
-
-	for i, ep := range host.Endpoints {
-		printEndpoint(i+1, &ep)
+// PrintReport imprime el reporte de forma legible, con la plantilla
+// templates/report.tmpl
+func PrintReport(w io.Writer, host *models.Host) {
+	if err := textReport.Execute(w, host); err != nil {
+		fmt.Fprintf(w, "\nError rendering report: %v\n", err)
 	}
 }
 
-func printEndpoint(num int, ep *models.Endpoint) {
-	fmt.Printf("\n[%d] IP Address: %s\n", num, ep.IPAddress)
-
-	if ep.ServerName != "" {
-		fmt.Printf("    Server Name: %s\n", ep.ServerName)
-	}
-
-	// Grade con color (simulado)
-	gradeColor := getGradeDisplay(ep.Grade)
-	fmt.Printf("    Grade: %s\n", gradeColor)
-
-	if ep.GradeTrustIgnored != "" && ep.GradeTrustIgnored != ep.Grade {
-		fmt.Printf("    Grade (Trust Ignored): %s\n", ep.GradeTrustIgnored)
-	}
-
-	fmt.Printf("    Status: %s\n", ep.StatusMessage)
-
-	if ep.HasWarnings {
-		fmt.Printf("Has Warnings\n")
+func getGradeDisplay(grade string) string {
+	// En una terminal real, podrías usar colores ANSI
+	switch grade {
+	case "A+", "A", "A-":
+		return "🟢 " + grade
+	case "B":
+		return "🟡 " + grade
+	case "C", "D":
+		return "🟠 " + grade
+	case "F", "T", "M":
+		return "🔴 " + grade
+	default:
+		return grade
 	}
+}
 
-	if ep.IsExceptional {
-		fmt.Printf("Exceptional Configuration\n")
+func getForwardSecrecyStatus(fs int) string {
+	if fs&4 != 0 {
+		return "Yes (with all simulated clients)"
+	} else if fs&2 != 0 {
+		return "Yes (with modern clients)"
+	} else if fs&1 != 0 {
+		return "Yes (with some clients)"
 	}
+	return "No"
+}
 
-	// Detalles si están disponibles
-	if ep.Details != nil {
-		printEndpointDetails(ep.Details)
+// ExportJSON exporta el resultado a JSON
+func ExportJSON(host *models.Host) (string, error) {
+	data, err := json.MarshalIndent(host, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling to JSON: %w", err)
 	}
+	return string(data), nil
 }
 
-func printEndpointDetails(details *models.EndpointDetails) {
-	fmt.Println("\n    === DETAILED INFORMATION ===")
+// PrintBatchSummary imprime un resumen con el resultado de cada host del batch
+func PrintBatchSummary(w io.Writer, batch *models.BatchResult) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+	fmt.Fprintf(w, "BATCH SUMMARY (%d hosts, %d failed)\n", len(batch.Results), batch.Failed())
+	fmt.Fprintln(w, strings.Repeat("=", 80))
 
-	// Protocolos
-	if len(details.Protocols) > 0 {
-		fmt.Printf("\n    Supported Protocols:\n")
-		for _, proto := range details.Protocols {
-			fmt.Printf("      - %s %s\n", proto.Name, proto.Version)
+	for _, entry := range batch.Results {
+		if !entry.Success() {
+			fmt.Fprintf(w, "  %-40s ERROR: %s\n", entry.Host, entry.Error)
+			continue
 		}
-	}
 
-	// Certificado
-	fmt.Printf("\n    Certificate:\n")
-	fmt.Printf("      Subject: %s\n", details.Cert.Subject)
-	fmt.Printf("      Issuer: %s\n", details.Cert.IssuerLabel)
-
-	notBefore := time.Unix(details.Cert.NotBefore/1000, 0)
-	notAfter := time.Unix(details.Cert.NotAfter/1000, 0)
-	fmt.Printf("      Valid From: %s\n", notBefore.Format("2006-01-02"))
-	fmt.Printf("      Valid Until: %s\n", notAfter.Format("2006-01-02"))
-
-	// Key
-	fmt.Printf("\n    Key:\n")
-	fmt.Printf("      Algorithm: %s\n", details.Key.Alg)
-	fmt.Printf("      Size: %d bits\n", details.Key.Size)
-	fmt.Printf("      Strength: %d bits\n", details.Key.Strength)
-
-	// Vulnerabilidades
-	fmt.Printf("\n    Security Issues:\n")
-	printVulnerabilities(details)
-
-	// Cipher Suites (primeros 5)
-	if len(details.Suites.List) > 0 {
-		fmt.Printf("\n    Cipher Suites (showing first 5 of %d):\n", len(details.Suites.List))
-		maxShow := 5
-		if len(details.Suites.List) < maxShow {
-			maxShow = len(details.Suites.List)
-		}
-		for i := 0; i < maxShow; i++ {
-			suite := details.Suites.List[i]
-			fmt.Printf("      - %s (%d bits)\n", suite.Name, suite.CipherStrength)
+		grades := make([]string, 0, len(entry.Result.Endpoints))
+		for _, ep := range entry.Result.Endpoints {
+			grades = append(grades, fmt.Sprintf("%s=%s", ep.IPAddress, ep.Grade))
 		}
+		fmt.Fprintf(w, "  %-40s OK: %s\n", entry.Host, strings.Join(grades, ", "))
 	}
 }
 
-func printVulnerabilities(details *models.EndpointDetails) {
-	hasVulnerabilities := false
-
-	if details.VulnBeast {
-		fmt.Printf("    BEAST: Vulnerable\n")
-		hasVulnerabilities = true
+// ExportBatchJSON exporta el resultado de un batch a JSON
+func ExportBatchJSON(batch *models.BatchResult) (string, error) {
+	data, err := json.MarshalIndent(batch, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("error marshaling to JSON: %w", err)
 	}
+	return string(data), nil
+}
 
-	if details.Heartbleed {
-		fmt.Printf("    Heartbleed: Vulnerable\n")
-		hasVulnerabilities = true
+// PrintComparison imprime las diferencias entre dos backends para un host
+func PrintComparison(w io.Writer, host string, differences []string) {
+	fmt.Fprintf(w, "\nENGINE COMPARISON: %s\n", host)
+	if len(differences) == 0 {
+		fmt.Fprintln(w, "  No differences")
+		return
 	}
-
-	if details.Poodle {
-		fmt.Printf("     POODLE (SSL): Vulnerable\n")
-		hasVulnerabilities = true
+	for _, diff := range differences {
+		fmt.Fprintf(w, "  - %s\n", diff)
 	}
+}
 
-	if details.PoodleTls == 2 {
-		fmt.Printf("     POODLE (TLS): Vulnerable\n")
-		hasVulnerabilities = true
-	}
+// PrintPolicyReport imprime el resultado de evaluar la política sobre un host.
+// Las violaciones con severidad menor que failOn se marcan como advertencias.
+func PrintPolicyReport(w io.Writer, report *policy.Report, failOn policy.Severity) {
+	violations := report.Violations(failOn)
 
-	if details.Freak {
-		fmt.Printf("      FREAK: Vulnerable\n")
-		hasVulnerabilities = true
+	status := "PASS"
+	if len(violations) > 0 {
+		status = "FAIL"
 	}
+	fmt.Fprintf(w, "\nPOLICY CHECK: %s (%s)\n", report.Host, status)
 
-	if details.Logjam {
-		fmt.Printf("      Logjam: Vulnerable\n")
-		hasVulnerabilities = true
+	for _, f := range report.Findings {
+		mark := "ok"
+		switch {
+		case !f.Passed && f.Severity.AtLeast(failOn):
+			mark = "FAIL"
+		case !f.Passed:
+			mark = "warn"
+		}
+		fmt.Fprintf(w, "  [%-4s] %-8s %s %s: %s\n", mark, f.Severity, f.Endpoint, f.RuleID, f.Message)
 	}
+}
 
-	if details.Rc4Only {
-		fmt.Printf("      RC4 Only\n")
-		hasVulnerabilities = true
+// PrintHistory imprime una tabla con los registros del historial
+func PrintHistory(w io.Writer, entries []storage.Entry) {
+	if len(entries) == 0 {
+		fmt.Fprintln(w, "No history records")
+		return
+	}
+
+	fmt.Fprintf(w, "%-30s %-22s %s\n", "HOST", "ID", "ENDPOINTS")
+	for _, entry := range entries {
+		grades := make([]string, 0, len(entry.Endpoints))
+		for _, ep := range entry.Endpoints {
+			grade := ep.Grade
+			if grade == "" {
+				grade = "-"
+			}
+			grades = append(grades, ep.IPAddress+" "+grade)
+		}
+		fmt.Fprintf(w, "%-30s %-22s %s\n", entry.Target(), entry.ID, strings.Join(grades, ", "))
 	}
+}
 
-	if !hasVulnerabilities {
-		fmt.Printf("      No major vulnerabilities detected\n")
+// PrintDiff imprime los cambios entre dos evaluaciones de un host
+func PrintDiff(w io.Writer, report *diff.Report) {
+	fmt.Fprintf(w, "DIFF: %s", report.Host)
+	if report.OldTestTime > 0 && report.NewTestTime > 0 {
+		fmt.Fprintf(w, " (%s -> %s)",
+			time.UnixMilli(report.OldTestTime).Format("2006-01-02 15:04"),
+			time.UnixMilli(report.NewTestTime).Format("2006-01-02 15:04"))
 	}
+	fmt.Fprintln(w)
 
-	// Forward Secrecy
-	fmt.Printf("\n      Forward Secrecy: %s\n", getForwardSecrecyStatus(details.ForwardSecrecy))
-}
-
-func getGradeDisplay(grade string) string {
-	// En una terminal real, podrías usar colores ANSI
-	switch grade {
-	case "A+", "A", "A-":
-		return "🟢 " + grade
-	case "B":
-		return "🟡 " + grade
-	case "C", "D":
-		return "🟠 " + grade
-	case "F", "T", "M":
-		return "🔴 " + grade
-	default:
-		return grade
+	if len(report.Changes) == 0 {
+		fmt.Fprintln(w, "  No changes")
+		return
 	}
-}
 
-func getForwardSecrecyStatus(fs int) string {
-	if fs&4 != 0 {
-		return "Yes (with all simulated clients)"
-	} else if fs&2 != 0 {
-		return "Yes (with modern clients)"
-	} else if fs&1 != 0 {
-		return "Yes (with some clients)"
+	last := ""
+	for _, change := range report.Changes {
+		if change.Endpoint != last {
+			fmt.Fprintf(w, "\n  %s\n", change.Endpoint)
+			last = change.Endpoint
+		}
+		fmt.Fprintf(w, "    - %s\n", change.Message)
 	}
-	return "No"
 }
 
-// ExportJSON exporta el resultado a JSON
-func ExportJSON(host *models.Host) (string, error) {
-	data, err := json.MarshalIndent(host, "", "  ")
-	if err != nil {
+// ExportDiffJSON exporta los cambios entre dos evaluaciones a JSON
+func ExportDiffJSON(report *diff.Report) (string, error) {
+	// Sin escapar HTML para que los mensajes ("A -> B") queden legibles
+	var buf strings.Builder
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
 		return "", fmt.Errorf("error marshaling to JSON: %w", err)
 	}
-	return string(data), nil
+	return strings.TrimSuffix(buf.String(), "\n"), nil
 }
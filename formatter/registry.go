@@ -0,0 +1,137 @@
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"NebulaChallenge/models"
+)
+
+// Formatter escribe el resultado de un análisis en un formato de salida.
+// batch incluye los hosts que fallaron; el análisis de un solo host llega
+// como un batch de un elemento.
+type Formatter interface {
+	Format(w io.Writer, batch *models.BatchResult) error
+}
+
+// FormatterFunc permite usar una función como Formatter
+type FormatterFunc func(w io.Writer, batch *models.BatchResult) error
+
+// Format llama a f(w, batch)
+func (f FormatterFunc) Format(w io.Writer, batch *models.BatchResult) error {
+	return f(w, batch)
+}
+
+// Registro de formatos por nombre y de extensiones de archivo por formato.
+// Register no es seguro para uso concurrente: se llama al iniciar.
+var (
+	registry   = map[string]Formatter{}
+	extensions = map[string]string{}
+)
+
+// Register añade (o reemplaza) el formato name. exts son las extensiones de
+// archivo (con punto, p. ej. ".sarif") que eligen este formato en --output.
+func Register(name string, f Formatter, exts ...string) {
+	registry[name] = f
+	for _, ext := range exts {
+		extensions[strings.ToLower(ext)] = name
+	}
+}
+
+// Lookup devuelve el formato registrado como name
+func Lookup(name string) (Formatter, error) {
+	f, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (use %s)", name, strings.Join(Names(), ", "))
+	}
+	return f, nil
+}
+
+// Names devuelve los nombres de los formatos registrados, ordenados
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ForPath devuelve el formato asociado a la extensión de path
+func ForPath(path string) (string, bool) {
+	name, ok := extensions[strings.ToLower(filepath.Ext(path))]
+	return name, ok
+}
+
+// exportFunc adapta una función Export* que genera todo el documento
+func exportFunc(export func(batch *models.BatchResult) (string, error)) Formatter {
+	return FormatterFunc(func(w io.Writer, batch *models.BatchResult) error {
+		out, err := export(batch)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, out)
+		return err
+	})
+}
+
+// NewJUnit crea el formato JUnit con las opciones indicadas. El formato
+// "junit" registrado por defecto usa JUnitOptions{}.
+func NewJUnit(opts JUnitOptions) Formatter {
+	return exportFunc(func(batch *models.BatchResult) (string, error) {
+		return ExportJUnit(batch, opts)
+	})
+}
+
+func init() {
+	Register("text", FormatterFunc(formatText), ".txt")
+	Register("json", FormatterFunc(formatJSON), ".json")
+	Register("ndjson", FormatterFunc(formatNDJSON), ".ndjson", ".jsonl")
+	Register("csv", exportFunc(ExportCSV), ".csv")
+	Register("sarif", exportFunc(ExportSARIF), ".sarif")
+	Register("junit", NewJUnit(JUnitOptions{}), ".xml")
+	Register("html", exportFunc(ExportHTML), ".html", ".htm")
+	Register("markdown", exportFunc(ExportMarkdown), ".md")
+}
+
+// formatText muestra el reporte de cada host (los que fallaron con Status
+// ERROR) y, si hay varios, el resumen
+func formatText(w io.Writer, batch *models.BatchResult) error {
+	for _, host := range batch.Reports() {
+		PrintReport(w, host)
+	}
+	if len(batch.Results) > 1 {
+		PrintBatchSummary(w, batch)
+	}
+	return nil
+}
+
+// formatJSON exporta un host como un documento y varios como el batch
+// completo, con los errores de cada host
+func formatJSON(w io.Writer, batch *models.BatchResult) error {
+	var out string
+	var err error
+	if hosts := batch.Hosts(); len(batch.Results) == 1 && len(hosts) == 1 {
+		out, err = ExportJSON(hosts[0])
+	} else {
+		out, err = ExportBatchJSON(batch)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, out)
+	return err
+}
+
+// formatNDJSON escribe una línea por host; los que fallaron con Status ERROR
+func formatNDJSON(w io.Writer, batch *models.BatchResult) error {
+	for _, host := range batch.Reports() {
+		if err := WriteNDJSON(w, host); err != nil {
+			return err
+		}
+	}
+	return nil
+}
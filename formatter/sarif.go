@@ -0,0 +1,457 @@
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+)
+
+const (
+	sarifVersion  = "2.1.0"
+	sarifSchema   = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolName = "nebula-challenge"
+
+	// sarifArtifactURI es la ubicación física de todos los resultados. Los
+	// hallazgos no están en un archivo del repositorio sino en un servidor,
+	// que va en las ubicaciones lógicas; los visores de code scanning exigen
+	// igualmente una ruta relativa y estable.
+	sarifArtifactURI = "tls-scan"
+
+	// Umbrales de las reglas de certificado que no vienen de un bitmask
+	sarifCertWarning  = 30 * 24 * time.Hour
+	sarifMinKeyBits   = 2048
+	sarifMinSuiteBits = 128
+)
+
+// sarifRule es una entrada del catálogo de reglas. El ID es estable entre
+// versiones para que los visores de code scanning puedan seguir cada hallazgo.
+type sarifRule struct {
+	id          string
+	name        string
+	description string
+	severity    policy.Severity
+}
+
+// Protocolos débiles, por nombre y versión tal como los informa SSL Labs
+var sarifProtocolRules = map[string]sarifRule{
+	"SSL 2.0": {"tls/weak-protocol/ssl2", "WeakProtocolSSL2", "SSL 2.0 is enabled", policy.SeverityCritical},
+	"SSL 3.0": {"tls/weak-protocol/ssl3", "WeakProtocolSSL3", "SSL 3.0 is enabled", policy.SeverityHigh},
+	"TLS 1.0": {"tls/weak-protocol/tls1.0", "WeakProtocolTLS10", "TLS 1.0 is enabled", policy.SeverityMedium},
+	"TLS 1.1": {"tls/weak-protocol/tls1.1", "WeakProtocolTLS11", "TLS 1.1 is enabled", policy.SeverityMedium},
+}
+
+var sarifProtocolOrder = []string{"SSL 2.0", "SSL 3.0", "TLS 1.0", "TLS 1.1"}
+
+// suiteRule clasifica un cipher suite débil. Se aplica la primera que coincide.
+type suiteRule struct {
+	sarifRule
+	match func(s models.Suite) bool
+}
+
+var sarifSuiteRules = []suiteRule{
+	{sarifRule{"tls/weak-suite/null", "NullCipherSuite", "Cipher suite without encryption", policy.SeverityCritical},
+		func(s models.Suite) bool { return strings.Contains(s.Name, "NULL") }},
+	{sarifRule{"tls/weak-suite/anon", "AnonymousCipherSuite", "Cipher suite without authentication", policy.SeverityCritical},
+		func(s models.Suite) bool { return strings.Contains(s.Name, "_anon_") }},
+	{sarifRule{"tls/weak-suite/export", "ExportCipherSuite", "Export-grade cipher suite", policy.SeverityHigh},
+		func(s models.Suite) bool { return strings.Contains(s.Name, "EXPORT") }},
+	{sarifRule{"tls/weak-suite/rc4", "RC4CipherSuite", "RC4 cipher suite", policy.SeverityHigh},
+		func(s models.Suite) bool { return strings.Contains(s.Name, "RC4") }},
+	{sarifRule{"tls/weak-suite/des", "DESCipherSuite", "DES or 3DES cipher suite", policy.SeverityMedium},
+		func(s models.Suite) bool { return strings.Contains(s.Name, "DES") }},
+	{sarifRule{"tls/weak-suite/md5", "MD5CipherSuite", "Cipher suite with an MD5 MAC", policy.SeverityMedium},
+		func(s models.Suite) bool { return strings.HasSuffix(s.Name, "_MD5") }},
+	{sarifRule{"tls/weak-suite/low-strength", "LowStrengthCipherSuite", "Cipher suite weaker than 128 bits", policy.SeverityMedium},
+		func(s models.Suite) bool { return s.CipherStrength > 0 && s.CipherStrength < sarifMinSuiteBits }},
+}
+
+// sarifSkippedVulnerabilities son los indicadores que ya cubre otra regla:
+// el soporte de RC4 se informa por cada suite (tls/weak-suite/rc4)
+var sarifSkippedVulnerabilities = map[string]bool{"rc4": true}
+
+// Severidad de cada indicador de models.Vulnerabilities. Los que no
+// aparecen se consideran high.
+var sarifVulnerabilitySeverity = map[string]policy.Severity{
+	"beast":       policy.SeverityLow,
+	"heartbleed":  policy.SeverityCritical,
+	"openssl_ccs": policy.SeverityCritical,
+	"dh_ys_reuse": policy.SeverityLow,
+}
+
+// Severidad de cada bit de Chain.Issues y Cert.Issues, por Key
+var sarifChainSeverity = map[string]policy.Severity{
+	"incomplete":       policy.SeverityMedium,
+	"unrelated":        policy.SeverityLow,
+	"incorrect_order":  policy.SeverityLow,
+	"self_signed_root": policy.SeverityLow,
+	"unverifiable":     policy.SeverityHigh,
+}
+
+var sarifCertSeverity = map[string]policy.Severity{
+	"no_chain_of_trust":  policy.SeverityHigh,
+	"not_yet_valid":      policy.SeverityHigh,
+	"expired":            policy.SeverityCritical,
+	"hostname_mismatch":  policy.SeverityHigh,
+	"revoked":            policy.SeverityCritical,
+	"bad_common_name":    policy.SeverityMedium,
+	"self_signed":        policy.SeverityHigh,
+	"blacklisted":        policy.SeverityCritical,
+	"insecure_signature": policy.SeverityHigh,
+}
+
+var (
+	sarifCertExpiring = sarifRule{"tls/certificate/expiring", "CertificateExpiringSoon",
+		"Certificate expires within 30 days", policy.SeverityMedium}
+	sarifWeakKey = sarifRule{"tls/certificate/weak-key", "WeakCertificateKey",
+		"Certificate key weaker than 2048 bits", policy.SeverityMedium}
+)
+
+// sarifRules devuelve el catálogo completo en un orden fijo, para que
+// ruleIndex no cambie entre ejecuciones
+func sarifRules() []sarifRule {
+	var rules []sarifRule
+	for _, version := range sarifProtocolOrder {
+		rules = append(rules, sarifProtocolRules[version])
+	}
+	for _, r := range sarifSuiteRules {
+		rules = append(rules, r.sarifRule)
+	}
+	for _, v := range models.Vulnerabilities {
+		if !sarifSkippedVulnerabilities[v.Key] {
+			rules = append(rules, vulnerabilityRule(v))
+		}
+	}
+	for _, f := range models.ChainIssueFlags {
+		rules = append(rules, issueRule("tls/chain/", "Chain", f, sarifChainSeverity))
+	}
+	for _, f := range models.CertIssueFlags {
+		rules = append(rules, issueRule("tls/certificate/", "Certificate", f, sarifCertSeverity))
+	}
+	return append(rules, sarifCertExpiring, sarifWeakKey)
+}
+
+func vulnerabilityRule(v models.Vulnerability) sarifRule {
+	severity, ok := sarifVulnerabilitySeverity[v.Key]
+	if !ok {
+		severity = policy.SeverityHigh
+	}
+	return sarifRule{
+		id:          "tls/vulnerability/" + strings.ReplaceAll(v.Key, "_", "-"),
+		name:        "Vulnerability" + camelCase(v.Key),
+		description: "Vulnerable to " + v.Name,
+		severity:    severity,
+	}
+}
+
+func issueRule(prefix, namePrefix string, f models.IssueFlag, severities map[string]policy.Severity) sarifRule {
+	severity, ok := severities[f.Key]
+	if !ok {
+		severity = policy.SeverityMedium
+	}
+	return sarifRule{
+		id:          prefix + strings.ReplaceAll(f.Key, "_", "-"),
+		name:        namePrefix + camelCase(f.Key),
+		description: f.Description,
+		severity:    severity,
+	}
+}
+
+// camelCase convierte "poodle_tls" en "PoodleTls"
+func camelCase(key string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(key, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// sarifLevel traduce la severidad al nivel de SARIF
+func sarifLevel(s policy.Severity) string {
+	switch s {
+	case policy.SeverityCritical, policy.SeverityHigh:
+		return "error"
+	case policy.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifSecuritySeverity es la puntuación CVSS aproximada que usa GitHub
+// code scanning para ordenar las alertas
+func sarifSecuritySeverity(s policy.Severity) string {
+	switch s {
+	case policy.SeverityCritical:
+		return "9.5"
+	case policy.SeverityHigh:
+		return "8.0"
+	case policy.SeverityMedium:
+		return "5.5"
+	default:
+		return "3.0"
+	}
+}
+
+// Estructuras del formato SARIF 2.1.0 (solo los campos que se usan)
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful        bool                `json:"executionSuccessful"`
+	ToolExecutionNotifications []sarifNotification `json:"toolExecutionNotifications,omitempty"`
+}
+
+type sarifNotification struct {
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name  string               `json:"name"`
+	Rules []sarifReportingRule `json:"rules"`
+}
+
+type sarifReportingRule struct {
+	ID                   string             `json:"id"`
+	Name                 string             `json:"name"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           sarifProperties    `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifProperties struct {
+	Tags             []string `json:"tags,omitempty"`
+	SecuritySeverity string   `json:"security-severity,omitempty"`
+	Severity         string   `json:"severity,omitempty"`
+	Target           string   `json:"target,omitempty"`
+	IPAddress        string   `json:"ipAddress,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             sarifMessage      `json:"message"`
+	Locations           []sarifLocation   `json:"locations"`
+	PartialFingerprints map[string]string `json:"partialFingerprints"`
+	Properties          sarifProperties   `json:"properties"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation *sarifPhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifLogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// ExportSARIF convierte los hallazgos de los hosts en un log SARIF 2.1.0:
+// un resultado por protocolo débil, cipher suite débil, vulnerabilidad,
+// problema de la cadena y problema del certificado de cada endpoint. Los
+// hosts que no se pudieron analizar se informan como notificaciones de error
+// de la ejecución (executionSuccessful: false).
+func ExportSARIF(batch *models.BatchResult) (string, error) {
+	rules := sarifRules()
+	index := make(map[string]int, len(rules))
+	driver := sarifDriver{Name: sarifToolName}
+	for i, r := range rules {
+		index[r.id] = i
+		driver.Rules = append(driver.Rules, sarifReportingRule{
+			ID:                   r.id,
+			Name:                 r.name,
+			ShortDescription:     sarifMessage{Text: r.description},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(r.severity)},
+			Properties: sarifProperties{
+				Tags:             []string{"security", "tls"},
+				SecuritySeverity: sarifSecuritySeverity(r.severity),
+			},
+		})
+	}
+
+	invocation := sarifInvocation{ExecutionSuccessful: batch.Failed() == 0}
+	results := []sarifResult{}
+	now := time.Now()
+	for _, entry := range batch.Results {
+		if !entry.Success() {
+			invocation.ToolExecutionNotifications = append(invocation.ToolExecutionNotifications, sarifNotification{
+				Level:   "error",
+				Message: sarifMessage{Text: fmt.Sprintf("%s: analysis failed: %s", entry.Host, entry.Error)},
+				Locations: []sarifLocation{{
+					LogicalLocations: []sarifLogicalLocation{{Name: entry.Host, FullyQualifiedName: entry.Host, Kind: "resource"}},
+				}},
+			})
+			continue
+		}
+
+		host := entry.Result
+		for _, ep := range host.Endpoints {
+			if ep.Details == nil {
+				continue
+			}
+			for _, f := range endpointFindings(ep.Details, now) {
+				results = append(results, newSarifResult(host, &ep, f, index[f.rule.id]))
+			}
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool:        sarifTool{Driver: driver},
+			Invocations: []sarifInvocation{invocation},
+			Results:     results,
+		}},
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(log); err != nil {
+		return "", fmt.Errorf("error encoding SARIF: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// sarifFinding es un hallazgo de un endpoint. subject distingue varios
+// resultados de la misma regla (p. ej. cada cipher suite).
+type sarifFinding struct {
+	rule    sarifRule
+	subject string
+	message string
+}
+
+func endpointFindings(d *models.EndpointDetails, now time.Time) []sarifFinding {
+	var findings []sarifFinding
+
+	for _, p := range d.Protocols {
+		version := p.Name + " " + p.Version
+		if rule, ok := sarifProtocolRules[version]; ok {
+			findings = append(findings, sarifFinding{rule, version, version + " is enabled"})
+		}
+	}
+
+	for _, s := range d.Suites.List {
+		for _, r := range sarifSuiteRules {
+			if r.match(s) {
+				findings = append(findings, sarifFinding{r.sarifRule, s.Name,
+					fmt.Sprintf("%s: %s (%d bits)", r.description, s.Name, s.CipherStrength)})
+				break
+			}
+		}
+	}
+
+	for _, v := range models.Vulnerabilities {
+		if v.Vulnerable(d) && !sarifSkippedVulnerabilities[v.Key] {
+			rule := vulnerabilityRule(v)
+			findings = append(findings, sarifFinding{rule, v.Key, rule.description})
+		}
+	}
+
+	for _, f := range models.ChainIssueFlags {
+		if d.Chain.Issues&f.Bit != 0 {
+			rule := issueRule("tls/chain/", "Chain", f, sarifChainSeverity)
+			findings = append(findings, sarifFinding{rule, f.Key, "Certificate chain: " + f.Description})
+		}
+	}
+
+	for _, f := range models.CertIssueFlags {
+		if d.Cert.Issues&f.Bit != 0 {
+			rule := issueRule("tls/certificate/", "Certificate", f, sarifCertSeverity)
+			findings = append(findings, sarifFinding{rule, f.Key,
+				fmt.Sprintf("Certificate %s: %s", d.Cert.Subject, f.Description)})
+		}
+	}
+
+	// Un certificado vencido ya se informa con su bit; aquí solo los próximos a vencer
+	if d.Cert.NotAfter > 0 {
+		notAfter := time.UnixMilli(d.Cert.NotAfter)
+		if left := notAfter.Sub(now); left > 0 && left < sarifCertWarning {
+			findings = append(findings, sarifFinding{sarifCertExpiring, d.Cert.SerialNumber,
+				fmt.Sprintf("Certificate %s expires on %s", d.Cert.Subject, notAfter.UTC().Format("2006-01-02"))})
+		}
+	}
+
+	if d.Key.Strength > 0 && d.Key.Strength < sarifMinKeyBits {
+		findings = append(findings, sarifFinding{sarifWeakKey, d.Key.Alg,
+			fmt.Sprintf("Certificate key is %s %d bits (strength %d)", d.Key.Alg, d.Key.Size, d.Key.Strength)})
+	}
+
+	return findings
+}
+
+func newSarifResult(host *models.Host, ep *models.Endpoint, f sarifFinding, ruleIndex int) sarifResult {
+	port := host.Port
+	if port == 0 {
+		port = 443
+	}
+	target := net.JoinHostPort(host.Host, strconv.Itoa(port))
+	qualified := target + "/" + ep.IPAddress
+
+	return sarifResult{
+		RuleID:    f.rule.id,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(f.rule.severity),
+		Message:   sarifMessage{Text: fmt.Sprintf("%s (%s): %s", host.Host, ep.IPAddress, f.message)},
+		// El servidor va como ubicación lógica: el host:puerto y, dentro, la IP
+		// del endpoint
+		Locations: []sarifLocation{{
+			PhysicalLocation: &sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifArtifactURI},
+			},
+			LogicalLocations: []sarifLogicalLocation{
+				{Name: target, FullyQualifiedName: target, Kind: "resource"},
+				{Name: ep.IPAddress, FullyQualifiedName: qualified, Kind: "resource"},
+			},
+		}},
+		// La huella no incluye el mensaje para que un cambio de texto no
+		// cree una alerta nueva
+		PartialFingerprints: map[string]string{
+			"tlsFinding/v1": qualified + "|" + f.rule.id + "|" + f.subject,
+		},
+		Properties: sarifProperties{Severity: string(f.rule.severity), Target: target, IPAddress: ep.IPAddress},
+	}
+}
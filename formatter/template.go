@@ -0,0 +1,156 @@
+package formatter
+
+import (
+	_ "embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// defaultTextTemplate es el reporte de texto de PrintReport. Se puede
+// copiar y modificar para usarlo con --template.
+//
+//go:embed templates/report.tmpl
+var defaultTextTemplate string
+
+var textReport = template.Must(template.New("report.tmpl").Funcs(TemplateFuncs()).Parse(defaultTextTemplate))
+
+// DefaultTextTemplate devuelve la plantilla del reporte de texto por defecto
+func DefaultTextTemplate() string {
+	return defaultTextTemplate
+}
+
+// TemplateFuncs devuelve las funciones disponibles en las plantillas de
+// --template, tanto text/template como html/template:
+//
+//	msTime         milisegundos desde epoch (TestTime, NotAfter...) a time.Time
+//	daysUntil      días desde ahora hasta una fecha en milisegundos
+//	round          redondea una duración: round .Age "1m"
+//	add            suma dos enteros (p. ej. índices desde 1)
+//	gradeDisplay   grado con su indicador de color, como el reporte de texto
+//	gradeClass     clase CSS del grado (grade-a, grade-b, grade-c, grade-f)
+//	forwardSecrecy descripción del bitmask de forward secrecy
+//	protocols      protocolos con ese nombre: protocols .Protocols "TLS"
+//	weakProtocols  SSL 2.0, SSL 3.0, TLS 1.0 y TLS 1.1 de la lista
+//	suites         cipher suites cuyo nombre contiene el texto
+//	weakSuites     cipher suites débiles (NULL, anon, EXPORT, RC4, DES, MD5, < 128 bits)
+//	limit          los primeros n cipher suites
+func TemplateFuncs() map[string]any {
+	return map[string]any{
+		"msTime":    func(ms int64) time.Time { return time.UnixMilli(ms) },
+		"daysUntil": daysUntil,
+		"round": func(d time.Duration, unit string) (time.Duration, error) {
+			m, err := time.ParseDuration(unit)
+			if err != nil {
+				return 0, err
+			}
+			return d.Round(m), nil
+		},
+		"add":            func(a, b int) int { return a + b },
+		"gradeDisplay":   getGradeDisplay,
+		"gradeClass":     gradeClass,
+		"forwardSecrecy": getForwardSecrecyStatus,
+		"protocols": func(list []models.Protocol, name string) []models.Protocol {
+			return filterProtocols(list, func(p models.Protocol) bool { return strings.EqualFold(p.Name, name) })
+		},
+		"weakProtocols": func(list []models.Protocol) []models.Protocol {
+			return filterProtocols(list, func(p models.Protocol) bool {
+				_, weak := sarifProtocolRules[p.Name+" "+p.Version]
+				return weak
+			})
+		},
+		"suites": func(list []models.Suite, substr string) []models.Suite {
+			return filterSuites(list, func(s models.Suite) bool {
+				return strings.Contains(strings.ToUpper(s.Name), strings.ToUpper(substr))
+			})
+		},
+		"weakSuites": func(list []models.Suite) []models.Suite {
+			return filterSuites(list, func(s models.Suite) bool {
+				for _, r := range sarifSuiteRules {
+					if r.match(s) {
+						return true
+					}
+				}
+				return false
+			})
+		},
+		"limit": func(list []models.Suite, n int) []models.Suite {
+			return list[:min(n, len(list))]
+		},
+	}
+}
+
+// daysUntil devuelve los días enteros que faltan hasta ms (negativo si ya pasó)
+func daysUntil(ms int64) int {
+	return int(time.Until(time.UnixMilli(ms)).Hours() / 24)
+}
+
+func filterProtocols(list []models.Protocol, keep func(models.Protocol) bool) []models.Protocol {
+	var out []models.Protocol
+	for _, p := range list {
+		if keep(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func filterSuites(list []models.Suite, keep func(models.Suite) bool) []models.Suite {
+	var out []models.Suite
+	for _, s := range list {
+		if keep(s) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// executor es la parte común de text/template y html/template
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// templateFormatter ejecuta la plantilla una vez por host; los que fallaron
+// llegan con Status "ERROR" y el error en StatusMessage
+type templateFormatter struct {
+	tmpl executor
+}
+
+func (t templateFormatter) Format(w io.Writer, batch *models.BatchResult) error {
+	for _, host := range batch.Reports() {
+		if err := t.tmpl.Execute(w, host); err != nil {
+			return fmt.Errorf("error rendering template: %w", err)
+		}
+	}
+	return nil
+}
+
+// NewTemplate crea un formato a partir de una plantilla de Go que recibe un
+// *models.Host. Los archivos .html y .htm (también .html.tmpl) se procesan
+// con html/template, que escapa los valores; el resto con text/template.
+func NewTemplate(path string) (Formatter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template: %w", err)
+	}
+
+	name := filepath.Base(path)
+	var tmpl executor
+	switch strings.ToLower(filepath.Ext(strings.TrimSuffix(name, ".tmpl"))) {
+	case ".html", ".htm":
+		tmpl, err = htmltemplate.New(name).Funcs(TemplateFuncs()).Parse(string(data))
+	default:
+		tmpl, err = template.New(name).Funcs(TemplateFuncs()).Parse(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %w", err)
+	}
+	return templateFormatter{tmpl: tmpl}, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/storage"
+)
+
+// runHistory implementa el comando "history list|show|prune" y devuelve el
+// código de salida
+func runHistory(args []string) int {
+	if len(args) == 0 {
+		printHistoryHelp()
+		return exitError
+	}
+
+	cmd, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("history "+cmd, flag.ContinueOnError)
+	dir := fs.String("history-dir", storage.DefaultDir(), "Directory of the scan history")
+
+	var err error
+	switch cmd {
+	case "list":
+		host := fs.String("host", "", "Only records of this host[:port]")
+		ip := fs.String("ip", "", "Only records with this endpoint IP address")
+		since := fs.Duration("since", 0, "Only records newer than this duration (e.g. 168h)")
+		asJSON := fs.Bool("json", false, "Output records as JSON")
+		if fs.Parse(args) != nil {
+			return exitError
+		}
+		err = historyList(*dir, *host, *ip, *since, *asJSON)
+	case "show":
+		asJSON := fs.Bool("json", false, "Output the record as JSON")
+		if fs.Parse(args) != nil {
+			return exitError
+		}
+		if fs.NArg() < 1 {
+			fmt.Fprintln(os.Stderr, "Usage: history show [--json] <host[:port]> [id|latest]")
+			return exitError
+		}
+		err = historyShow(*dir, fs.Arg(0), fs.Arg(1), *asJSON)
+	case "prune":
+		olderThan := fs.Duration("older-than", 30*24*time.Hour, "Remove records older than this duration")
+		keep := fs.Int("keep", 1, "Always keep this many most recent records per host and IP")
+		if fs.Parse(args) != nil {
+			return exitError
+		}
+		err = historyPrune(*dir, *olderThan, *keep)
+	case "help", "--help", "-h":
+		printHistoryHelp()
+		return exitOK
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown history command %q\n", cmd)
+		printHistoryHelp()
+		return exitError
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+func historyList(dir, host, ip string, since time.Duration, asJSON bool) error {
+	store, err := storage.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	filter := storage.Filter{Host: host, IPAddress: ip}
+	if since > 0 {
+		filter.Since = time.Now().Add(-since)
+	}
+
+	entries, err := store.List(filter)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error exporting JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	formatter.PrintHistory(os.Stdout, entries)
+	return nil
+}
+
+func historyShow(dir, host, id string, asJSON bool) error {
+	store, err := storage.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	result, err := store.Load(host, id)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		jsonOutput, err := formatter.ExportJSON(result)
+		if err != nil {
+			return fmt.Errorf("error exporting JSON: %w", err)
+		}
+		fmt.Println(jsonOutput)
+		return nil
+	}
+
+	formatter.PrintReport(os.Stdout, result)
+	return nil
+}
+
+func historyPrune(dir string, olderThan time.Duration, keep int) error {
+	if keep < 0 {
+		return errors.New("--keep must be zero or positive")
+	}
+
+	store, err := storage.Open(dir)
+	if err != nil {
+		return err
+	}
+
+	removed, err := store.Prune(time.Now().Add(-olderThan), keep)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed %d history record(s)\n", removed)
+	return nil
+}
+
+func printHistoryHelp() {
+	fmt.Println("Usage:")
+	fmt.Println("  nebula-challenge history list [--host=<host[:port]>] [--ip=<ip>] [--since=<duration>] [--json]")
+	fmt.Println("  nebula-challenge history show [--json] <host[:port]> [id|latest]")
+	fmt.Println("  nebula-challenge history prune [--older-than=<duration>] [--keep=<n>]")
+	fmt.Println("\nEvery command accepts --history-dir (default ~/.nebula-challenge/history).")
+}
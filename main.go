@@ -2,70 +2,398 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"NebulaChallenge/analyzer"
 	"NebulaChallenge/formatter"
+	"NebulaChallenge/models"
+	"NebulaChallenge/storage"
+	"NebulaChallenge/utils"
 )
 
+// Códigos de salida del proceso
+const (
+	exitOK              = 0
+	exitError           = 1   // Error de análisis o de uso
+	exitPolicyViolation = 2   // Algún host no cumple la política de --policy
+	exitGradeFailure    = 3   // Algún endpoint no alcanza --min-grade
+	exitRateLimited     = 4   // SSL Labs rechazó las peticiones por límite o sobrecarga
+	exitCancelled       = 130 // Convención de shell para SIGINT
+)
+
+// hostList permite repetir el flag --host
+type hostList []string
+
+func (h *hostList) String() string {
+	return strings.Join(*h, ",")
+}
+
+func (h *hostList) Set(value string) error {
+	*h = append(*h, value)
+	return nil
+}
+
 func main() {
+	// Subcomandos
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "history":
+			os.Exit(runHistory(os.Args[2:]))
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		}
+	}
+
 	// Definir flags
-	hostPtr := flag.String("host", "", "Hostname to analyze (required)")
+	var hosts hostList
+	flag.Var(&hosts, "host", "Hostname to analyze (repeatable)")
+	hostsFilePtr := flag.String("hosts-file", "", "File with one hostname per line ('-' for stdin)")
+	concurrencyPtr := flag.Int("concurrency", 4, "Maximum concurrent assessments in batch mode")
 	publishPtr := flag.Bool("publish", false, "Publish results on SSL Labs boards")
-	jsonPtr := flag.Bool("json", false, "Output results as JSON")
+	jsonPtr := flag.Bool("json", false, "Output results as JSON (same as --format=json)")
+	formatPtr := flag.String("format", "text", "Output format: "+strings.Join(formatter.Names(), ", "))
+	var outputSpecs hostList
+	templatePtr := flag.String("template", "", "Go template file to render each host with (text/template, or html/template for .html)")
+	printTemplatePtr := flag.Bool("print-template", false, "Print the built-in text report template and exit")
+	flag.Var(&outputSpecs, "output", "Write the results to this file as [format:]path; the format defaults to the file extension (repeatable, '-' for stdout)")
+	pollIntervalPtr := flag.Duration("poll-interval", analyzer.DefaultPollInterval, "Polling interval while the analysis is queued")
+	progressIntervalPtr := flag.Duration("progress-interval", analyzer.DefaultInProgressPollInterval, "Polling interval once the analysis is in progress")
+	maxWaitPtr := flag.Duration("max-wait", 0, "Maximum total wait per host (0 = no limit)")
+	detailConcurrencyPtr := flag.Int("detail-concurrency", analyzer.DefaultDetailConcurrency, "Maximum concurrent getEndpointData requests per host")
+	fromCachePtr := flag.Bool("from-cache", false, "Use a cached SSL Labs report when available instead of starting a new assessment")
+	maxAgePtr := flag.Int("max-age", 0, "Maximum age in hours of a cached report with --from-cache (0 = any)")
+	var engines engineConfig
+	engines.register(flag.CommandLine)
+	minGradePtr := flag.String("min-grade", "", "Minimum grade every endpoint must reach (e.g. A-)")
+	ignoreTrustPtr := flag.Bool("ignore-trust", false, "Compare the grade ignoring trust issues (gradeTrustIgnored)")
+	policyPtr := flag.String("policy", "", "YAML or JSON policy file to check results against")
+	policyFailOnPtr := flag.String("policy-fail-on", "low", "Minimum severity that fails the policy check: low, medium, high or critical")
+	historyDirPtr := flag.String("history-dir", storage.DefaultDir(), "Directory where completed assessments are saved")
+	noHistoryPtr := flag.Bool("no-history", false, "Do not save completed assessments to the history")
+	metricsFilePtr := flag.String("metrics-file", "", "Write Prometheus metrics to this file (node_exporter textfile collector)")
+	ndjsonPtr := flag.String("ndjson", "", "Append one JSON line per completed host to this file as soon as it finishes")
+	verbosePtr := flag.Bool("verbose", false, "Also log rate-limit waits and polling to stderr (retries are always logged)")
 	helpPtr := flag.Bool("help", false, "Show help")
 
 	flag.Parse()
 
 	if *helpPtr {
 		printHelp()
-		os.Exit(0)
+		os.Exit(exitOK)
 	}
 
-	if *hostPtr == "" {
+	if *printTemplatePtr {
+		fmt.Print(formatter.DefaultTextTemplate())
+		os.Exit(exitOK)
+	}
+
+	allHosts, err := collectHosts(hosts, *hostsFilePtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	if len(allHosts) == 0 {
 		fmt.Println("Error: --host flag is required")
 		fmt.Println("Use --help for more information")
-		os.Exit(1)
+		os.Exit(exitError)
+	}
+
+	if *maxAgePtr < 0 {
+		fmt.Fprintln(os.Stderr, "Error: --max-age must be zero or positive")
+		os.Exit(exitError)
+	}
+
+	// El formato JUnit evalúa el grado con los mismos criterios que --min-grade
+	formatter.Register("junit", formatter.NewJUnit(formatter.JUnitOptions{
+		MinGrade:    *minGradePtr,
+		IgnoreTrust: *ignoreTrustPtr,
+	}), ".xml")
+
+	format := *formatPtr
+	if *templatePtr != "" {
+		tmpl, err := formatter.NewTemplate(*templatePtr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		formatter.Register("template", tmpl)
+		// --template sin --format explícito cambia el formato de stdout
+		if !flagSet("format") {
+			format = "template"
+		}
+	}
+	if *jsonPtr {
+		format = "json"
+	}
+	if _, err := formatter.Lookup(format); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	outputs, err := parseOutputs(outputSpecs, format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	// Si stdout recibe JSON, CSV, etc. el reporte de política va a stderr
+	policyOut := io.Writer(os.Stdout)
+	if machineStdout(outputs) {
+		policyOut = os.Stderr
+	}
+	var checks resultChecks
+	checks.policy, err = newPolicyGate(*policyPtr, *policyFailOnPtr, policyOut)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+	checks.grade, err = newGradeGate(*minGradePtr, *ignoreTrustPtr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	rec := recorder{metricsFile: *metricsFilePtr}
+	if *ndjsonPtr != "" {
+		f, err := os.OpenFile(*ndjsonPtr, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitError)
+		}
+		defer f.Close()
+		rec.ndjson = f
+	}
+	if !*noHistoryPtr {
+		rec.history, err = storage.Open(*historyDirPtr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: history disabled: %v\n", err)
+		}
 	}
 
 	// Setup context para manejar Ctrl+C
-	_, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Capturar Ctrl+C
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	// Las advertencias (p. ej. la espera antes de reintentar cuando SSL Labs
+	// está sobrecargado) se muestran siempre
+	level := slog.LevelWarn
+	if *verbosePtr {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
 
-	go func() {
-		<-sigChan
-		fmt.Println("\n\nAnalysis cancelled by user")
-		cancel()
-		os.Exit(0)
-	}()
+	// Crear el backend de análisis pedido
+	engines.logger = logger
+	backend, err := engines.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
+	}
 
-	// Crear analizador y ejecutar
-	a := analyzer.NewAnalyzer()
+	// Crear analizador y ejecutar. WithHostDone no se llama de forma
+	// concurrente, así que done no necesita lock.
+	done := 0
+	opts := []analyzer.Option{
+		analyzer.WithScanner(backend),
+		analyzer.WithPollIntervals(*pollIntervalPtr, *progressIntervalPtr),
+		analyzer.WithMaxWait(*maxWaitPtr),
+		analyzer.WithDetailConcurrency(*detailConcurrencyPtr),
+		analyzer.WithLogger(logger),
+		analyzer.WithBatchStart(printBatchStart),
+		analyzer.WithHostDone(func(entry *models.HostResult) {
+			done++
+			printBatchProgress(done, len(allHosts), entry)
+			rec.stream(entry.Report())
+		}),
+	}
+	if *fromCachePtr {
+		opts = append(opts, analyzer.WithCache(*maxAgePtr))
+	}
+	a := analyzer.NewAnalyzer(opts...)
+
+	if len(allHosts) > 1 {
+		code := runBatch(ctx, a, allHosts, *publishPtr, *concurrencyPtr, outputs, checks, rec)
+		printComparisons(backend, allHosts)
+		os.Exit(code)
+	}
 
-	result, err := a.Run(*hostPtr, *publishPtr)
+	result, err := a.Run(ctx, allHosts[0], *publishPtr)
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(os.Stderr, "\n\nAnalysis cancelled by user")
+			if result != nil {
+				fmt.Fprintln(os.Stderr, "Partial results:")
+				printResult(outputs, allHosts[0], result)
+			}
+			os.Exit(exitCancelled)
+		}
+		if errors.Is(err, analyzer.ErrMaxWaitExceeded) && result != nil {
+			fmt.Fprintln(os.Stderr, "Partial results:")
+		}
+		// El host fallido también queda en las salidas (JUnit, SARIF...) para
+		// que no parezca un análisis correcto. Sin resultado parcial, en
+		// stdout el texto sería solo el mensaje de error.
+		failed := singleBatch(allHosts[0], result, err)
+		for _, out := range outputs {
+			if out.path == "-" && out.format == "text" && result == nil {
+				continue
+			}
+			if outErr := out.write(failed); outErr != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", outErr)
+			}
+		}
+		rec.stream(failed.Results[0].Report())
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(errorExitCode(err))
 	}
 
-	// Mostrar resultados
-	if *jsonPtr {
-		jsonOutput, err := formatter.ExportJSON(result)
+	// Mostrar y guardar resultados
+	printResult(outputs, allHosts[0], result)
+	rec.stream(result)
+	rec.record(result)
+	printComparisons(backend, allHosts)
+
+	os.Exit(checks.run(result))
+}
+
+// collectHosts junta los hosts de --host, --hosts-file y stdin
+func collectHosts(flagHosts []string, hostsFile string) ([]string, error) {
+	hosts := append([]string{}, flagHosts...)
+
+	var r io.Reader
+	switch {
+	case hostsFile == "-":
+		r = os.Stdin
+	case hostsFile != "":
+		f, err := os.Open(hostsFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error exporting JSON: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("error opening hosts file: %w", err)
+		}
+		defer f.Close()
+		r = f
+	case len(hosts) == 0 && stdinIsPipe():
+		r = os.Stdin
+	}
+
+	if r != nil {
+		fileHosts, err := utils.ReadHosts(r)
+		if err != nil {
+			return nil, err
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+
+	return hosts, nil
+}
+
+// stdinIsPipe indica si stdin viene de un pipe o archivo y no de una terminal
+func stdinIsPipe() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice == 0
+}
+
+// flagSet indica si el flag name se pasó en la línea de comandos
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
 		}
-		fmt.Println(jsonOutput)
-	} else {
-		formatter.PrintReport(result)
+	})
+	return set
+}
+
+// runBatch analiza varios hosts y devuelve el código de salida
+func runBatch(ctx context.Context, a *analyzer.Analyzer, hosts []string, publish bool, concurrency int, outputs []output, checks resultChecks, rec recorder) int {
+	batch, err := a.RunBatch(ctx, hosts, publish, concurrency)
+	if batch == nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return errorExitCode(err)
+	}
+
+	return finishBatch(batch, err, outputs, checks, rec)
+}
+
+// printBatchStart muestra la API y los workers antes de empezar el batch
+func printBatchStart(start analyzer.BatchStart) {
+	if start.Info != nil {
+		fmt.Fprintf(os.Stderr, "SSL Labs API v%s (Criteria: %s)\n", start.Info.Version, start.Info.CriteriaVersion)
+		fmt.Fprintf(os.Stderr, "Analyzing %d hosts with %d workers (max assessments: %d, current: %d)\n\n",
+			start.Hosts, start.Workers, start.MaxAssessments, start.CurrentAssessments)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Analyzing %d hosts with %d workers (engine: %s)\n\n", start.Hosts, start.Workers, start.Engine)
+}
+
+// printBatchProgress muestra una línea por cada host terminado
+func printBatchProgress(done, total int, entry *models.HostResult) {
+	if entry.Success() {
+		grades := ""
+		missing := 0
+		for i, ep := range entry.Result.Endpoints {
+			if i > 0 {
+				grades += ", "
+			}
+			grades += ep.Grade
+			if ep.DetailsError != "" {
+				missing++
+			}
+		}
+		if missing > 0 {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s: done (%s), details missing for %d endpoint(s)\n", done, total, entry.Host, grades, missing)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %s: done (%s)\n", done, total, entry.Host, grades)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "[%d/%d] %s: error: %s\n", done, total, entry.Host, entry.Error)
+}
+
+// finishBatch escribe el resultado de un batch en cada salida, evalúa la
+// política sobre los hosts analizados y devuelve el código de salida
+func finishBatch(batch *models.BatchResult, err error, outputs []output, checks resultChecks, rec recorder) int {
+	if outErr := writeOutputs(outputs, batch); outErr != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", outErr)
+		return exitError
+	}
+
+	analyzed := batch.Hosts()
+	rec.record(analyzed...)
+	code := checks.run(analyzed...)
+
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "\n\nAnalysis cancelled by user")
+		return exitCancelled
+	}
+	if failed := batch.Failed(); failed > 0 {
+		if batch.RateLimited() == failed {
+			return exitRateLimited
+		}
+		return exitError
+	}
+	return code
+}
+
+// printResult escribe el resultado de un host en cada salida
+func printResult(outputs []output, host string, result *models.Host) {
+	if err := writeOutputs(outputs, singleBatch(host, result, nil)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitError)
 	}
 }
 
@@ -73,13 +401,77 @@ func printHelp() {
 	fmt.Println("Nebula Challenge - SSL Labs Security Scanner")
 	fmt.Println("\nUsage:")
 	fmt.Println("  nebula-challenge --host=<hostname> [options]")
+	fmt.Println("  nebula-challenge history list|show|prune [options]")
+	fmt.Println("  nebula-challenge diff [--json] <old.json> <new.json>")
+	fmt.Println("  nebula-challenge watch --host=<hostname> [--schedule=<spec>] [--alert=<sink>] [options]")
+	fmt.Println("  nebula-challenge serve [--addr=:8080] [--workers=2] [options]")
 	fmt.Println("\nOptions:")
-	fmt.Println("  --host string      Hostname to analyze (required)")
-	fmt.Println("  --publish          Publish results on SSL Labs public boards")
-	fmt.Println("  --json             Output results as JSON")
-	fmt.Println("  --help             Show this help message")
+	fmt.Println("  --host string          Hostname to analyze (repeat for batch mode)")
+	fmt.Println("  --hosts-file string    File with one hostname per line ('-' for stdin)")
+	fmt.Println("  --concurrency int      Maximum concurrent assessments in batch mode (default 4)")
+	fmt.Println("  --publish              Publish results on SSL Labs public boards")
+	fmt.Println("  --json                 Output results as JSON (same as --format=json)")
+	fmt.Println("  --format string        Output format: text, json, ndjson, csv, sarif, junit, html or")
+	fmt.Println("                         markdown (default text)")
+	fmt.Println("  --template string      Go template rendered once per host; selects the \"template\"")
+	fmt.Println("                         format (html/template for .html files, see README)")
+	fmt.Println("  --print-template       Print the built-in text report template and exit")
+	fmt.Println("  --output [fmt:]path    Write the results to a file instead of stdout; the format")
+	fmt.Println("                         defaults to the extension (repeatable, '-' for stdout)")
+	fmt.Println("  --api-version string   SSL Labs API version: 2, 3 or 4 (default 2)")
+	fmt.Println("  --email string         Registered email for API v4 (default $SSLLABS_EMAIL)")
+	fmt.Println("  --base-url string      SSL Labs API base URL (e.g. an internal mirror)")
+	fmt.Println("  --max-retry-after dur  Maximum wait before retrying when SSL Labs is overloaded;")
+	fmt.Println("                         0 = the API suggestion, up to 30m (default 1m)")
+	fmt.Println("  --poll-interval dur    Polling interval while the analysis is queued (default 5s)")
+	fmt.Println("  --progress-interval dur Polling interval once the analysis is in progress (default 10s)")
+	fmt.Println("  --max-wait dur         Maximum total wait per host, 0 = no limit (default 0)")
+	fmt.Println("  --detail-concurrency int Maximum concurrent getEndpointData requests per host (default 4)")
+	fmt.Println("  --from-cache           Use a cached SSL Labs report when available")
+	fmt.Println("  --max-age int          Maximum age in hours of a cached report, 0 = any (default 0)")
+	fmt.Println("  --engine string        Analysis backend: ssllabs, local, fixture or two of them")
+	fmt.Println("                         separated by a comma, e.g. ssllabs,local (default ssllabs)")
+	fmt.Println("  --composite-mode string How to combine two engines: merge or compare (default merge)")
+	fmt.Println("  --fixtures string      Directory with <host>.json files for the fixture engine")
+	fmt.Println("  --min-grade string     Minimum grade every endpoint must reach; exits 3 otherwise")
+	fmt.Println("  --ignore-trust         Compare the grade ignoring trust issues (gradeTrustIgnored)")
+	fmt.Println("  --policy string        YAML or JSON policy file; exits 2 on violations")
+	fmt.Println("  --policy-fail-on string Minimum severity that fails the check (default low)")
+	fmt.Println("  --history-dir string   Directory where completed assessments are saved")
+	fmt.Println("                         (default ~/.nebula-challenge/history)")
+	fmt.Println("  --no-history           Do not save completed assessments to the history")
+	fmt.Println("  --metrics-file string  Write Prometheus metrics to this file (node_exporter textfile)")
+	fmt.Println("  --ndjson string        Append one JSON line per completed host as it finishes")
+	fmt.Println("  --verbose              Also log rate-limit waits and polling to stderr")
+	fmt.Println("  --help                 Show this help message")
+	fmt.Println("\nCommands:")
+	fmt.Println("  history list           List saved assessments (--host, --ip, --since, --json)")
+	fmt.Println("  history show           Show a saved assessment: history show [--json] <host> [id|latest]")
+	fmt.Println("  history prune          Remove old assessments (--older-than, --keep)")
+	fmt.Println("  diff                   Show what changed between two saved assessments of a host")
+	fmt.Println("  watch                  Re-assess hosts on a schedule and alert on regressions")
+	fmt.Println("                         (--schedule, --alert, --cert-warn-days; see README)")
+	fmt.Println("  serve                  Expose scans as a REST API (POST /scans, GET /scans/{id},")
+	fmt.Println("                         GET /scans/{id}/report)")
+	fmt.Println("\nExit codes:")
+	fmt.Println("  0 success, 1 analysis error, 2 policy violation, 3 grade below --min-grade,")
+	fmt.Println("  4 rate limited by SSL Labs, 130 cancelled")
 	fmt.Println("\nExamples:")
 	fmt.Println("  go run . --host=google.com")
 	fmt.Println("  go run . --host=facebook.com --json")
 	fmt.Println("  go run . --host=github.com --publish")
+	fmt.Println("  go run . --host=google.com --host=github.com")
+	fmt.Println("  go run . --hosts-file=domains.txt --concurrency=2")
+	fmt.Println("  cat domains.txt | go run .")
+	fmt.Println("  go run . --host=google.com --api-version=4 --email=me@example.com")
+	fmt.Println("  go run . --host=google.com --from-cache --max-age=24")
+	fmt.Println("  go run . --host=intranet.example.com:8443 --engine=local")
+	fmt.Println("  go run . --host=example.com --engine=ssllabs,local --composite-mode=compare")
+	fmt.Println("  go run . --host=example.com --min-grade=A-")
+	fmt.Println("  go run . --host=example.com --policy=examples/policy.yaml --policy-fail-on=high")
+	fmt.Println("  go run . --host=example.com --format=markdown")
+	fmt.Println("  go run . --host=example.com --output=tls.sarif --output=report.html --output=-")
+	fmt.Println("  go run . --hosts-file=domains.txt --output=junit:tls-junit.xml --min-grade=A-")
+	fmt.Println("  go run . --hosts-file=domains.txt --output=tls.csv --ndjson=tls.ndjson")
+	fmt.Println("  go run . --host=example.com --template=team.html.tmpl --output=template:report.html")
 }
@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+)
+
+// protocols son las versiones que se informan siempre en
+// tls_protocol_supported, soportadas o no
+var protocols = []string{"SSL 2.0", "SSL 3.0", "TLS 1.0", "TLS 1.1", "TLS 1.2", "TLS 1.3"}
+
+// family es una métrica con sus muestras
+type family struct {
+	name    string
+	help    string
+	samples []sample
+}
+
+type sample struct {
+	labels []string // Pares nombre, valor
+	value  float64
+}
+
+func (f *family) add(value float64, labels ...string) {
+	f.samples = append(f.samples, sample{labels: labels, value: value})
+}
+
+// GradeScore convierte un grado en un número para poder graficarlo y
+// alertar con umbrales: A+ = 9, A = 8, A- = 7, B = 6, C = 5, D = 4, E = 3,
+// F = 2, T = 1, M = 0. Devuelve false si el grado no es conocido.
+func GradeScore(grade string) (float64, bool) {
+	rank := policy.GradeRank(grade)
+	if rank < 0 {
+		return 0, false
+	}
+	return float64(policy.GradeCount() - 1 - rank), true
+}
+
+// Write escribe las métricas de los hosts en el formato de texto de
+// Prometheus, con una serie por host, puerto e IP. Si un host y puerto
+// aparece varias veces se usa su evaluación más reciente.
+func Write(w io.Writer, hosts []*models.Host) error {
+	var (
+		grade = &family{name: "tls_grade_score",
+			help: "SSL Labs grade as a number (A+ = 9 ... F = 2, T = 1, M = 0)"}
+		certExpiry = &family{name: "tls_cert_expiry_timestamp_seconds",
+			help: "Leaf certificate expiry (notAfter) as a Unix timestamp"}
+		keyStrength = &family{name: "tls_key_strength_bits",
+			help: "Strength of the certificate key in bits"}
+		protocol = &family{name: "tls_protocol_supported",
+			help: "Whether the endpoint supports the protocol version (1) or not (0)"}
+		vulnerable = &family{name: "tls_vulnerable",
+			help: "Whether the endpoint is vulnerable (1) or not (0), one series per vulnerability flag"}
+		duration = &family{name: "tls_scan_duration_seconds",
+			help: "Time SSL Labs spent assessing the endpoint"}
+		scanTime = &family{name: "tls_scan_timestamp_seconds",
+			help: "When the assessment finished, as a Unix timestamp"}
+	)
+
+	for _, host := range latest(hosts) {
+		port := strconv.Itoa(hostPort(host))
+		if host.TestTime > 0 {
+			scanTime.add(float64(host.TestTime)/1000, "host", host.Host, "port", port)
+		}
+
+		for _, ep := range host.Endpoints {
+			labels := []string{"host", host.Host, "port", port, "ip", ep.IPAddress}
+
+			if score, ok := GradeScore(ep.Grade); ok {
+				grade.add(score, labels...)
+			}
+			if ep.Duration > 0 {
+				duration.add(float64(ep.Duration)/1000, labels...)
+			}
+
+			details := ep.Details
+			if details == nil {
+				continue
+			}
+
+			if details.Cert.NotAfter > 0 {
+				certExpiry.add(float64(details.Cert.NotAfter)/1000, labels...)
+			}
+			keyStrength.add(float64(details.Key.Strength), labels...)
+
+			for _, version := range protocols {
+				supported := 0.0
+				for _, p := range details.Protocols {
+					if p.Name+" "+p.Version == version {
+						supported = 1
+						break
+					}
+				}
+				protocol.add(supported, append(labels, "protocol", version)...)
+			}
+
+			for _, v := range models.Vulnerabilities {
+				value := 0.0
+				if v.Vulnerable(details) {
+					value = 1
+				}
+				vulnerable.add(value, append(labels, "vulnerability", v.Key)...)
+			}
+		}
+	}
+
+	for _, f := range []*family{grade, certExpiry, keyStrength, protocol, vulnerable, duration, scanTime} {
+		if err := f.write(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFile escribe las métricas en path para el textfile collector de
+// node_exporter. Se escribe en un temporal y se renombra para que el
+// collector nunca lea un archivo a medias.
+func WriteFile(path string, hosts []*models.Host) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".metrics-*")
+	if err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, hosts); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error writing metrics: %w", err)
+	}
+	return nil
+}
+
+func (f *family) write(w io.Writer) error {
+	if len(f.samples) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# HELP %s %s\n", f.name, f.help)
+	fmt.Fprintf(&b, "# TYPE %s gauge\n", f.name)
+	for _, s := range f.samples {
+		b.WriteString(f.name)
+		b.WriteByte('{')
+		for i := 0; i < len(s.labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", s.labels[i], escapeLabel(s.labels[i+1]))
+		}
+		b.WriteString("} ")
+		b.WriteString(strconv.FormatFloat(s.value, 'f', -1, 64))
+		b.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapa un valor de etiqueta según el formato de texto
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// latest se queda con el resultado más reciente de cada host y puerto (la
+// clave de ScanRequest.Target), para que una lista con evaluaciones
+// repetidas no genere series duplicadas
+func latest(hosts []*models.Host) []*models.Host {
+	latest := make(map[string]*models.Host)
+	var order []string
+	for _, h := range hosts {
+		key := models.ScanRequest{Host: h.Host, Port: hostPort(h)}.Target()
+		prev, ok := latest[key]
+		if !ok {
+			order = append(order, key)
+		}
+		if !ok || h.TestTime >= prev.TestTime {
+			latest[key] = h
+		}
+	}
+
+	result := make([]*models.Host, 0, len(order))
+	for _, key := range order {
+		result = append(result, latest[key])
+	}
+	return result
+}
+
+// hostPort es el puerto analizado; los resultados sin puerto son del 443
+func hostPort(h *models.Host) int {
+	if h.Port == 0 {
+		return 443
+	}
+	return h.Port
+}
+
+// Handler sirve en cada petición las métricas de los hosts que devuelve source
+func Handler(source func() []*models.Host) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := Write(w, source()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package models
+
+// BatchResult agrupa los resultados de un análisis de varios hosts
+type BatchResult struct {
+	Results []HostResult `json:"results"`
+}
+
+// HostResult es el resultado de un host dentro de un batch
+type HostResult struct {
+	Host   string `json:"host"`
+	Result *Host  `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+
+	// RateLimited indica que el error se debió al límite de peticiones de la API
+	RateLimited bool `json:"rateLimited,omitempty"`
+}
+
+// Success indica si el análisis del host terminó sin errores
+func (r *HostResult) Success() bool {
+	return r.Error == "" && r.Result != nil
+}
+
+// Failed devuelve el número de hosts que terminaron con error
+func (b *BatchResult) Failed() int {
+	failed := 0
+	for i := range b.Results {
+		if !b.Results[i].Success() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// RateLimited devuelve el número de hosts que fallaron por límite de peticiones
+func (b *BatchResult) RateLimited() int {
+	limited := 0
+	for i := range b.Results {
+		if b.Results[i].RateLimited {
+			limited++
+		}
+	}
+	return limited
+}
+
+// Hosts devuelve los resultados de los hosts que terminaron sin errores, en
+// el orden del batch
+func (b *BatchResult) Hosts() []*Host {
+	var hosts []*Host
+	for i := range b.Results {
+		if b.Results[i].Success() {
+			hosts = append(hosts, b.Results[i].Result)
+		}
+	}
+	return hosts
+}
+
+// Report devuelve el resultado del host para los formatos de salida. Si el
+// análisis falló es un Host con Status "ERROR" y el error en StatusMessage,
+// como los análisis fallidos de SSL Labs (conserva el resultado parcial si
+// lo hay).
+func (r *HostResult) Report() *Host {
+	if r.Success() {
+		return r.Result
+	}
+	host := &Host{Host: r.Host}
+	if r.Result != nil {
+		partial := *r.Result
+		host = &partial
+	}
+	host.Status = "ERROR"
+	host.StatusMessage = r.Error
+	return host
+}
+
+// Reports devuelve Report de cada host del batch, incluidos los que fallaron
+func (b *BatchResult) Reports() []*Host {
+	hosts := make([]*Host, 0, len(b.Results))
+	for i := range b.Results {
+		hosts = append(hosts, b.Results[i].Report())
+	}
+	return hosts
+}
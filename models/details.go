@@ -35,8 +35,9 @@ type EndpointDetails struct {
 	SniRequired              bool   `json:"sniRequired"`
 
 	// HTTP
-	HTTPStatusCode int    `json:"httpStatusCode,omitempty"`
-	HTTPForwarding string `json:"httpForwarding,omitempty"`
+	HTTPStatusCode int         `json:"httpStatusCode,omitempty"`
+	HTTPForwarding string      `json:"httpForwarding,omitempty"`
+	HstsPolicy     *HstsPolicy `json:"hstsPolicy,omitempty"`
 
 	// RC4 y Forward Secrecy
 	SupportsRc4    bool `json:"supportsRc4"`
@@ -52,6 +53,9 @@ type EndpointDetails struct {
 	DhUsesKnownPrimes  int      `json:"dhUsesKnownPrimes,omitempty"`
 	DhYsReuse          bool     `json:"dhYsReuse,omitempty"`
 	ChaCha20Preference bool     `json:"chaCha20Preference,omitempty"`
+
+	// Simulación de clientes
+	Sims *SimDetails `json:"sims,omitempty"`
 }
 
 // Key representa información de la clave
@@ -66,6 +70,7 @@ type Key struct {
 // Cert representa información del certificado
 type Cert struct {
 	Subject              string   `json:"subject"`
+	SerialNumber         string   `json:"serialNumber,omitempty"`
 	CommonNames          []string `json:"commonNames"`
 	AltNames             []string `json:"altNames"`
 	NotBefore            int64    `json:"notBefore"`
@@ -85,6 +90,17 @@ type Cert struct {
 	Sct                  bool     `json:"sct"`
 }
 
+// HstsPolicy representa la política HSTS del servidor
+type HstsPolicy struct {
+	LongMaxAge        int64  `json:"LONG_MAX_AGE"`
+	Header            string `json:"header,omitempty"`
+	Status            string `json:"status"` // unknown, absent, present, invalid, disabled, error
+	Error             string `json:"error,omitempty"`
+	MaxAge            int64  `json:"maxAge,omitempty"`
+	IncludeSubDomains bool   `json:"includeSubDomains,omitempty"`
+	Preload           bool   `json:"preload,omitempty"`
+}
+
 // Chain representa la cadena de certificados
 type Chain struct {
 	Certs  []ChainCert `json:"certs"`
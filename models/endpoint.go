@@ -15,4 +15,8 @@ type Endpoint struct {
 	ETA                  int              `json:"eta,omitempty"`
 	Delegation           int              `json:"delegation,omitempty"`
 	Details              *EndpointDetails `json:"details,omitempty"`
+
+	// DetailsError explica por qué no se pudieron obtener los detalles
+	// con getEndpointData. No forma parte de la respuesta de SSL Labs.
+	DetailsError string `json:"detailsError,omitempty"`
 }
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type Host struct {
 	Host            string     `json:"host"`
 	Port            int        `json:"port"`
@@ -15,3 +17,12 @@ type Host struct {
 	Endpoints       []Endpoint `json:"endPoints"`
 	CertHostnames   []string   `json:"certHostnames,omitempty"`
 }
+
+// Age devuelve el tiempo transcurrido desde que se completó el análisis,
+// o 0 si el resultado no informa TestTime
+func (h *Host) Age() time.Duration {
+	if h.TestTime <= 0 {
+		return 0
+	}
+	return time.Since(time.UnixMilli(h.TestTime))
+}
@@ -0,0 +1,63 @@
+package models
+
+// Bits de Cert.Issues según la documentación de SSL Labs
+const (
+	CertIssueNoChainOfTrust    = 1 << 0
+	CertIssueNotBefore         = 1 << 1
+	CertIssueNotAfter          = 1 << 2
+	CertIssueHostnameMismatch  = 1 << 3
+	CertIssueRevoked           = 1 << 4
+	CertIssueBadCommonName     = 1 << 5
+	CertIssueSelfSigned        = 1 << 6
+	CertIssueBlacklisted       = 1 << 7
+	CertIssueInsecureSignature = 1 << 8
+)
+
+// Bits de Chain.Issues según la documentación de SSL Labs
+const (
+	ChainIssueIncomplete     = 1 << 1
+	ChainIssueUnrelated      = 1 << 2
+	ChainIssueIncorrectOrder = 1 << 3
+	ChainIssueSelfSignedRoot = 1 << 4
+	ChainIssueUnverifiable   = 1 << 5
+)
+
+// IssueFlag asocia un bit de un bitmask con su descripción
+type IssueFlag struct {
+	Bit         int
+	Description string
+	Key         string // Identificador estable, p. ej. para IDs de reglas SARIF
+}
+
+// CertIssueFlags describe cada bit de Cert.Issues
+var CertIssueFlags = []IssueFlag{
+	{CertIssueNoChainOfTrust, "No chain of trust", "no_chain_of_trust"},
+	{CertIssueNotBefore, "Not yet valid", "not_yet_valid"},
+	{CertIssueNotAfter, "Expired", "expired"},
+	{CertIssueHostnameMismatch, "Hostname mismatch", "hostname_mismatch"},
+	{CertIssueRevoked, "Revoked", "revoked"},
+	{CertIssueBadCommonName, "Bad common name", "bad_common_name"},
+	{CertIssueSelfSigned, "Self-signed", "self_signed"},
+	{CertIssueBlacklisted, "Blacklisted", "blacklisted"},
+	{CertIssueInsecureSignature, "Insecure signature", "insecure_signature"},
+}
+
+// ChainIssueFlags describe cada bit de Chain.Issues
+var ChainIssueFlags = []IssueFlag{
+	{ChainIssueIncomplete, "Incomplete chain", "incomplete"},
+	{ChainIssueUnrelated, "Chain contains unrelated or duplicate certificates", "unrelated"},
+	{ChainIssueIncorrectOrder, "Certificates are in the wrong order", "incorrect_order"},
+	{ChainIssueSelfSignedRoot, "Chain contains a self-signed root certificate", "self_signed_root"},
+	{ChainIssueUnverifiable, "Chain cannot be validated", "unverifiable"},
+}
+
+// ActiveIssues devuelve las descripciones de los bits activos en mask
+func ActiveIssues(mask int, flags []IssueFlag) []string {
+	var active []string
+	for _, f := range flags {
+		if mask&f.Bit != 0 {
+			active = append(active, f.Description)
+		}
+	}
+	return active
+}
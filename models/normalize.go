@@ -0,0 +1,226 @@
+package models
+
+import "strings"
+
+// NormalizeV3Host convierte una respuesta v3/v4 al modelo interno Host
+func NormalizeV3Host(v3 *V3Host) *Host {
+	certs := IndexV3Certs(v3.Certs)
+
+	host := &Host{
+		Host:            v3.Host,
+		Port:            v3.Port,
+		Protocol:        v3.Protocol,
+		IsPublic:        v3.IsPublic,
+		Status:          v3.Status,
+		StatusMessage:   v3.StatusMessage,
+		StartTime:       v3.StartTime,
+		TestTime:        v3.TestTime,
+		EngineVersion:   v3.EngineVersion,
+		CriteriaVersion: v3.CriteriaVersion,
+		CacheExpiryTime: v3.CacheExpiryTime,
+		CertHostnames:   v3.CertHostnames,
+	}
+
+	for i := range v3.Endpoints {
+		host.Endpoints = append(host.Endpoints, *NormalizeV3Endpoint(&v3.Endpoints[i], certs))
+	}
+
+	return host
+}
+
+// IndexV3Certs indexa los certificados de host.certs por ID
+func IndexV3Certs(certs []V3Cert) map[string]V3Cert {
+	index := make(map[string]V3Cert, len(certs))
+	for _, cert := range certs {
+		index[cert.ID] = cert
+	}
+	return index
+}
+
+// NormalizeV3Endpoint convierte un endpoint v3/v4 al modelo interno.
+// certs permite resolver los certIds de las cadenas.
+func NormalizeV3Endpoint(v3 *V3Endpoint, certs map[string]V3Cert) *Endpoint {
+	ep := &Endpoint{
+		IPAddress:            v3.IPAddress,
+		ServerName:           v3.ServerName,
+		StatusMessage:        v3.StatusMessage,
+		StatusDetails:        v3.StatusDetails,
+		StatusDetailsMessage: v3.StatusDetailsMessage,
+		Grade:                v3.Grade,
+		GradeTrustIgnored:    v3.GradeTrustIgnored,
+		HasWarnings:          v3.HasWarnings,
+		IsExceptional:        v3.IsExceptional,
+		Progress:             v3.Progress,
+		Duration:             v3.Duration,
+		ETA:                  v3.ETA,
+		Delegation:           v3.Delegation,
+	}
+
+	if v3.Details != nil {
+		ep.Details = normalizeV3Details(v3.Details, certs)
+	}
+
+	return ep
+}
+
+func normalizeV3Details(v3 *V3EndpointDetails, certs map[string]V3Cert) *EndpointDetails {
+	details := &EndpointDetails{
+		HostStartTime:            v3.HostStartTime,
+		Protocols:                v3.Protocols,
+		ServerSignature:          v3.ServerSignature,
+		PrefixDelegation:         v3.PrefixDelegation,
+		NonPrefixDelegation:      v3.NonPrefixDelegation,
+		VulnBeast:                v3.VulnBeast,
+		Heartbleed:               v3.Heartbleed,
+		Heartbeat:                v3.Heartbeat,
+		Poodle:                   v3.Poodle,
+		PoodleTls:                v3.PoodleTls,
+		Freak:                    v3.Freak,
+		Logjam:                   v3.Logjam,
+		RenegSupport:             v3.RenegSupport,
+		SessionResumption:        v3.SessionResumption,
+		CompressionMethods:       v3.CompressionMethods,
+		SupportsNpn:              v3.SupportsNpn,
+		NpnProtocols:             v3.NpnProtocols,
+		SessionTickets:           v3.SessionTickets,
+		OcspStapling:             v3.OcspStapling,
+		StaplingRevocationStatus: v3.StaplingRevocationStatus,
+		SniRequired:              v3.SniRequired,
+		HTTPStatusCode:           v3.HTTPStatusCode,
+		HTTPForwarding:           v3.HTTPForwarding,
+		HstsPolicy:               v3.HstsPolicy,
+		SupportsRc4:              v3.SupportsRc4,
+		Rc4WithModern:            v3.Rc4WithModern,
+		Rc4Only:                  v3.Rc4Only,
+		ForwardSecrecy:           v3.ForwardSecrecy,
+		OpenSslCcs:               v3.OpenSslCcs,
+		FallbackScsv:             v3.FallbackScsv,
+		HasSct:                   v3.HasSct,
+		DhPrimes:                 v3.DhPrimes,
+		DhUsesKnownPrimes:        v3.DhUsesKnownPrimes,
+		DhYsReuse:                v3.DhYsReuse,
+		ChaCha20Preference:       v3.ChaCha20Preference,
+		Sims:                     v3.Sims,
+	}
+
+	// Cadena de certificados: se usa la primera cadena (la servida con SNI)
+	if len(v3.CertChains) > 0 {
+		chain := v3.CertChains[0]
+		details.Chain.Issues = chain.Issues
+
+		for i, id := range chain.CertIDs {
+			cert, ok := certs[id]
+			if !ok {
+				continue
+			}
+			if i == 0 {
+				details.Cert = normalizeV3Cert(&cert)
+				details.Key = Key{
+					Size:       cert.KeySize,
+					Strength:   cert.KeyStrength,
+					Alg:        cert.KeyAlg,
+					DebianFlaw: cert.KeyKnownDebianInsecure,
+				}
+			}
+			details.Chain.Certs = append(details.Chain.Certs, normalizeV3ChainCert(&cert))
+		}
+	}
+
+	// Cipher suites: v3 los separa por protocolo, el modelo interno usa una
+	// única lista sin duplicados
+	seen := make(map[int]bool)
+	for _, protoSuites := range v3.Suites {
+		if protoSuites.Preference {
+			details.Suites.Preference = true
+		}
+		if protoSuites.ChaCha20Preference {
+			details.ChaCha20Preference = true
+		}
+		for _, suite := range protoSuites.List {
+			if seen[suite.ID] {
+				continue
+			}
+			seen[suite.ID] = true
+			details.Suites.List = append(details.Suites.List, normalizeV3Suite(&suite))
+		}
+	}
+
+	return details
+}
+
+func normalizeV3Cert(cert *V3Cert) Cert {
+	return Cert{
+		Subject:              cert.Subject,
+		SerialNumber:         cert.SerialNumber,
+		CommonNames:          cert.CommonNames,
+		AltNames:             cert.AltNames,
+		NotBefore:            cert.NotBefore,
+		NotAfter:             cert.NotAfter,
+		IssuerSubject:        cert.IssuerSubject,
+		SigAlg:               cert.SigAlg,
+		IssuerLabel:          commonName(cert.IssuerSubject),
+		RevocationInfo:       cert.RevocationInfo,
+		CrlURIs:              cert.CrlURIs,
+		OcspURIs:             cert.OcspURIs,
+		RevocationStatus:     cert.RevocationStatus,
+		CrlRevocationStatus:  cert.CrlRevocationStatus,
+		OcspRevocationStatus: cert.OcspRevocationStatus,
+		Sgc:                  cert.Sgc,
+		ValidationType:       cert.ValidationType,
+		Issues:               cert.Issues,
+		Sct:                  cert.Sct,
+	}
+}
+
+func normalizeV3ChainCert(cert *V3Cert) ChainCert {
+	return ChainCert{
+		Subject:              cert.Subject,
+		Label:                commonName(cert.Subject),
+		NotBefore:            cert.NotBefore,
+		NotAfter:             cert.NotAfter,
+		IssuerSubject:        cert.IssuerSubject,
+		IssuerLabel:          commonName(cert.IssuerSubject),
+		SigAlg:               cert.SigAlg,
+		Issues:               cert.Issues,
+		KeyAlg:               cert.KeyAlg,
+		KeySize:              cert.KeySize,
+		KeyStrength:          cert.KeyStrength,
+		RevocationStatus:     cert.RevocationStatus,
+		CrlRevocationStatus:  cert.CrlRevocationStatus,
+		OcspRevocationStatus: cert.OcspRevocationStatus,
+		Raw:                  cert.Raw,
+	}
+}
+
+func normalizeV3Suite(suite *V3Suite) Suite {
+	normalized := Suite{
+		ID:             suite.ID,
+		Name:           suite.Name,
+		CipherStrength: suite.CipherStrength,
+		DhP:            suite.DhP,
+		DhG:            suite.DhG,
+		DhYs:           suite.DhYs,
+		Q:              suite.Q,
+	}
+
+	switch suite.KxType {
+	case "DH":
+		normalized.DhStrength = suite.KxStrength
+	case "ECDH":
+		normalized.EcdhBits = suite.NamedGroupBits
+		normalized.EcdhStrength = suite.KxStrength
+	}
+
+	return normalized
+}
+
+// commonName extrae el CN de un distinguished name, o devuelve el DN completo
+func commonName(dn string) string {
+	for _, part := range strings.Split(dn, ",") {
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "CN=") {
+			return strings.TrimPrefix(part, "CN=")
+		}
+	}
+	return dn
+}
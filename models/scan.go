@@ -0,0 +1,22 @@
+package models
+
+import (
+	"net"
+	"strconv"
+)
+
+// ScanRequest describe el análisis pedido a un backend
+type ScanRequest struct {
+	Host    string
+	Port    int // 0 = puerto por defecto del backend
+	Publish bool
+}
+
+// Target devuelve "host" o "host:port" si se indicó un puerto ("[v6]:port"
+// para una IPv6)
+func (r ScanRequest) Target() string {
+	if r.Port == 0 {
+		return r.Host
+	}
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
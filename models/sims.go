@@ -0,0 +1,109 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SimDetails contiene la simulación de handshake de clientes conocidos
+type SimDetails struct {
+	Results []Simulation `json:"results"`
+}
+
+// Simulation es el resultado del handshake de un cliente simulado
+type Simulation struct {
+	Client       SimClient `json:"client"`
+	ErrorCode    int       `json:"errorCode"`              // 0 = handshake correcto
+	ErrorMessage string    `json:"errorMessage,omitempty"` // v3/v4
+	Attempts     int       `json:"attempts"`
+	ProtocolID   int       `json:"protocolId,omitempty"`
+	SuiteID      int       `json:"suiteId,omitempty"`
+	SuiteName    string    `json:"suiteName,omitempty"` // v3/v4
+	KxInfo       string    `json:"kxInfo,omitempty"`    // v2
+	KxType       string    `json:"kxType,omitempty"`    // v3/v4
+	KxStrength   int       `json:"kxStrength,omitempty"`
+	AlertType    int       `json:"alertType,omitempty"`
+	AlertCode    int       `json:"alertCode,omitempty"`
+}
+
+// SimClient describe el cliente simulado
+type SimClient struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Platform    string `json:"platform,omitempty"`
+	Version     string `json:"version"`
+	IsReference bool   `json:"isReference"`
+}
+
+// String devuelve el nombre y versión del cliente, con la plataforma si la
+// tiene: "Android 4.4.2", "IE 11 / Win 7"
+func (c SimClient) String() string {
+	label := strings.TrimSpace(c.Name + " " + c.Version)
+	if c.Platform != "" {
+		label += " / " + c.Platform
+	}
+	return label
+}
+
+// Matches indica si name identifica al cliente, sin distinguir mayúsculas:
+// el nombre completo ("IE 11 / Win 7") o sin plataforma ("IE 11", que
+// incluye todas las plataformas)
+func (c SimClient) Matches(name string) bool {
+	name = strings.TrimSpace(name)
+	return strings.EqualFold(name, c.String()) ||
+		strings.EqualFold(name, strings.TrimSpace(c.Name+" "+c.Version))
+}
+
+// Connected indica si el cliente completó el handshake
+func (s Simulation) Connected() bool {
+	return s.ErrorCode == 0
+}
+
+// Failed devuelve cuántos clientes no completaron el handshake
+func (s *SimDetails) Failed() int {
+	failed := 0
+	for _, sim := range s.Results {
+		if !sim.Connected() {
+			failed++
+		}
+	}
+	return failed
+}
+
+// protocolIDs son los IDs de protocolo de SSL Labs (versión del record TLS)
+var protocolIDs = map[int]string{
+	0x0200: "SSL 2.0",
+	0x0300: "SSL 3.0",
+	0x0301: "TLS 1.0",
+	0x0302: "TLS 1.1",
+	0x0303: "TLS 1.2",
+	0x0304: "TLS 1.3",
+}
+
+// ProtocolName devuelve el nombre del protocolo con ese ID, p. ej. el
+// negociado en una simulación
+func (d *EndpointDetails) ProtocolName(id int) string {
+	for _, p := range d.Protocols {
+		if p.ID == id {
+			return p.Name + " " + p.Version
+		}
+	}
+	if name, ok := protocolIDs[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", id)
+}
+
+// SimSuite devuelve el nombre del cipher suite negociado en la simulación.
+// v2 solo envía el ID, que se busca en la lista de suites del endpoint.
+func (d *EndpointDetails) SimSuite(sim Simulation) string {
+	if sim.SuiteName != "" {
+		return sim.SuiteName
+	}
+	for _, s := range d.Suites.List {
+		if s.ID == sim.SuiteID {
+			return s.Name
+		}
+	}
+	return fmt.Sprintf("0x%x", sim.SuiteID)
+}
@@ -0,0 +1,149 @@
+package models
+
+// Estructuras de las respuestas de las APIs v3 y v4 de SSL Labs.
+// Ambas versiones comparten formato; se normalizan a Host con NormalizeV3Host
+// para que el resto de la aplicación trabaje con una única representación.
+
+// V3Host es la respuesta de /analyze en v3/v4
+type V3Host struct {
+	Host            string       `json:"host"`
+	Port            int          `json:"port"`
+	Protocol        string       `json:"protocol"`
+	IsPublic        bool         `json:"isPublic"`
+	Status          string       `json:"status"`
+	StatusMessage   string       `json:"statusMessage,omitempty"`
+	StartTime       int64        `json:"startTime"`
+	TestTime        int64        `json:"testTime,omitempty"`
+	EngineVersion   string       `json:"engineVersion,omitempty"`
+	CriteriaVersion string       `json:"criteriaVersion,omitempty"`
+	CacheExpiryTime int64        `json:"cacheExpiryTime,omitempty"`
+	Endpoints       []V3Endpoint `json:"endpoints"`
+	CertHostnames   []string     `json:"certHostnames,omitempty"`
+	Certs           []V3Cert     `json:"certs"`
+}
+
+// V3Endpoint es un endpoint en v3/v4
+type V3Endpoint struct {
+	IPAddress            string             `json:"ipAddress"`
+	ServerName           string             `json:"serverName,omitempty"`
+	StatusMessage        string             `json:"statusMessage"`
+	StatusDetails        string             `json:"statusDetails,omitempty"`
+	StatusDetailsMessage string             `json:"statusDetailsMessage,omitempty"`
+	Grade                string             `json:"grade,omitempty"`
+	GradeTrustIgnored    string             `json:"gradeTrustIgnored,omitempty"`
+	HasWarnings          bool               `json:"hasWarnings"`
+	IsExceptional        bool               `json:"isExceptional"`
+	Progress             int                `json:"progress"`
+	Duration             int                `json:"duration"`
+	ETA                  int                `json:"eta,omitempty"`
+	Delegation           int                `json:"delegation,omitempty"`
+	Details              *V3EndpointDetails `json:"details,omitempty"`
+}
+
+// V3EndpointDetails contiene los detalles de un endpoint en v3/v4
+type V3EndpointDetails struct {
+	HostStartTime int64              `json:"hostStartTime"`
+	CertChains    []V3CertChain      `json:"certChains"`
+	Protocols     []Protocol         `json:"protocols"`
+	Suites        []V3ProtocolSuites `json:"suites"`
+
+	ServerSignature     string `json:"serverSignature,omitempty"`
+	PrefixDelegation    bool   `json:"prefixDelegation"`
+	NonPrefixDelegation bool   `json:"nonPrefixDelegation"`
+
+	VulnBeast  bool `json:"vulnBeast"`
+	Heartbleed bool `json:"heartbleed"`
+	Heartbeat  bool `json:"heartbeat"`
+	Poodle     bool `json:"poodle"`
+	PoodleTls  int  `json:"poodleTls"`
+	Freak      bool `json:"freak"`
+	Logjam     bool `json:"logjam"`
+
+	RenegSupport             int    `json:"renegSupport"`
+	SessionResumption        int    `json:"sessionResumption"`
+	CompressionMethods       int    `json:"compressionMethods"`
+	SupportsNpn              bool   `json:"supportsNpn"`
+	NpnProtocols             string `json:"npnProtocols,omitempty"`
+	SessionTickets           int    `json:"sessionTickets"`
+	OcspStapling             bool   `json:"ocspStapling"`
+	StaplingRevocationStatus int    `json:"staplingRevocationStatus,omitempty"`
+	SniRequired              bool   `json:"sniRequired"`
+
+	HTTPStatusCode int         `json:"httpStatusCode,omitempty"`
+	HTTPForwarding string      `json:"httpForwarding,omitempty"`
+	HstsPolicy     *HstsPolicy `json:"hstsPolicy,omitempty"`
+
+	SupportsRc4    bool `json:"supportsRc4"`
+	Rc4WithModern  bool `json:"rc4WithModern"`
+	Rc4Only        bool `json:"rc4Only"`
+	ForwardSecrecy int  `json:"forwardSecrecy"`
+
+	OpenSslCcs         int      `json:"openSslCcs"`
+	FallbackScsv       bool     `json:"fallbackScsv,omitempty"`
+	HasSct             int      `json:"hasSct"`
+	DhPrimes           []string `json:"dhPrimes,omitempty"`
+	DhUsesKnownPrimes  int      `json:"dhUsesKnownPrimes,omitempty"`
+	DhYsReuse          bool     `json:"dhYsReuse,omitempty"`
+	ChaCha20Preference bool     `json:"chaCha20Preference,omitempty"`
+
+	Sims *SimDetails `json:"sims,omitempty"`
+}
+
+// V3CertChain es una cadena de certificados que referencia certs por ID
+type V3CertChain struct {
+	ID      string   `json:"id"`
+	CertIDs []string `json:"certIds"`
+	Issues  int      `json:"issues"`
+	NoSni   bool     `json:"noSni,omitempty"`
+}
+
+// V3Cert es un certificado de la lista host.certs
+type V3Cert struct {
+	ID                     string   `json:"id"`
+	Subject                string   `json:"subject"`
+	SerialNumber           string   `json:"serialNumber"`
+	CommonNames            []string `json:"commonNames"`
+	AltNames               []string `json:"altNames"`
+	NotBefore              int64    `json:"notBefore"`
+	NotAfter               int64    `json:"notAfter"`
+	IssuerSubject          string   `json:"issuerSubject"`
+	SigAlg                 string   `json:"sigAlg"`
+	RevocationInfo         int      `json:"revocationInfo"`
+	CrlURIs                []string `json:"crlURIs"`
+	OcspURIs               []string `json:"ocspURIs"`
+	RevocationStatus       int      `json:"revocationStatus"`
+	CrlRevocationStatus    int      `json:"crlRevocationStatus"`
+	OcspRevocationStatus   int      `json:"ocspRevocationStatus"`
+	Sgc                    int      `json:"sgc"`
+	ValidationType         string   `json:"validationType,omitempty"`
+	Issues                 int      `json:"issues"`
+	Sct                    bool     `json:"sct"`
+	KeyAlg                 string   `json:"keyAlg"`
+	KeySize                int      `json:"keySize"`
+	KeyStrength            int      `json:"keyStrength"`
+	KeyKnownDebianInsecure bool     `json:"keyKnownDebianInsecure"`
+	Raw                    string   `json:"raw"`
+}
+
+// V3ProtocolSuites agrupa los cipher suites de un protocolo
+type V3ProtocolSuites struct {
+	Protocol           int       `json:"protocol"`
+	List               []V3Suite `json:"list"`
+	Preference         bool      `json:"preference"`
+	ChaCha20Preference bool      `json:"chaCha20Preference,omitempty"`
+}
+
+// V3Suite representa un cipher suite en v3/v4
+type V3Suite struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	CipherStrength int    `json:"cipherStrength"`
+	KxType         string `json:"kxType,omitempty"`
+	KxStrength     int    `json:"kxStrength,omitempty"`
+	DhP            int    `json:"dhP,omitempty"`
+	DhG            int    `json:"dhG,omitempty"`
+	DhYs           int    `json:"dhYs,omitempty"`
+	NamedGroupBits int    `json:"namedGroupBits,omitempty"`
+	NamedGroupName string `json:"namedGroupName,omitempty"`
+	Q              *int   `json:"q"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// Vulnerability describe un indicador de vulnerabilidad de EndpointDetails
+type Vulnerability struct {
+	Key        string // Identificador estable, p. ej. para etiquetas de métricas
+	Name       string // Nombre legible
+	Vulnerable func(d *EndpointDetails) bool
+}
+
+// Vulnerabilities enumera los indicadores de vulnerabilidad que informa SSL Labs
+var Vulnerabilities = []Vulnerability{
+	{"beast", "BEAST", func(d *EndpointDetails) bool { return d.VulnBeast }},
+	{"heartbleed", "Heartbleed", func(d *EndpointDetails) bool { return d.Heartbleed }},
+	{"poodle", "POODLE (SSL)", func(d *EndpointDetails) bool { return d.Poodle }},
+	{"poodle_tls", "POODLE (TLS)", func(d *EndpointDetails) bool { return d.PoodleTls == 2 }},
+	{"freak", "FREAK", func(d *EndpointDetails) bool { return d.Freak }},
+	{"logjam", "Logjam", func(d *EndpointDetails) bool { return d.Logjam }},
+	{"openssl_ccs", "OpenSSL CCS", func(d *EndpointDetails) bool { return d.OpenSslCcs == 3 }},
+	{"rc4", "RC4", func(d *EndpointDetails) bool { return d.SupportsRc4 }},
+	{"rc4_only", "RC4 only", func(d *EndpointDetails) bool { return d.Rc4Only }},
+	{"dh_ys_reuse", "DH public key reuse", func(d *EndpointDetails) bool { return d.DhYsReuse }},
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"NebulaChallenge/client"
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/models"
+)
+
+// output es un destino de --output: un formato del registro y un archivo
+// ("-" = stdout)
+type output struct {
+	format string
+	f      formatter.Formatter
+	path   string
+}
+
+// parseOutputs interpreta los valores de --output. Cada uno es
+// [formato:]ruta; sin prefijo el formato sale de la extensión del archivo
+// y, si no es conocida, de --format. Sin --output se escribe --format en
+// stdout.
+func parseOutputs(specs []string, format string) ([]output, error) {
+	if len(specs) == 0 {
+		specs = []string{"-"}
+	}
+
+	outputs := make([]output, 0, len(specs))
+	for _, spec := range specs {
+		name, path := "", spec
+		if prefix, rest, ok := strings.Cut(spec, ":"); ok {
+			// Solo se toma como formato si está registrado (C:\... es una ruta)
+			if _, err := formatter.Lookup(prefix); err == nil {
+				name, path = prefix, rest
+			}
+		}
+		if name == "" {
+			name = format
+			if byExt, ok := formatter.ForPath(path); ok {
+				name = byExt
+			}
+		}
+		if path == "" {
+			return nil, fmt.Errorf("invalid --output %q: missing path", spec)
+		}
+
+		f, err := formatter.Lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, output{format: name, f: f, path: path})
+	}
+	return outputs, nil
+}
+
+// machineStdout indica si stdout recibe un formato que no es texto, en cuyo
+// caso los mensajes para personas deben ir a stderr
+func machineStdout(outputs []output) bool {
+	for _, out := range outputs {
+		if out.path == "-" && out.format != "text" {
+			return true
+		}
+	}
+	return false
+}
+
+// writeOutputs escribe el batch en cada destino
+func writeOutputs(outputs []output, batch *models.BatchResult) error {
+	for _, out := range outputs {
+		if err := out.write(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o output) write(batch *models.BatchResult) error {
+	if o.path == "-" {
+		if err := o.f.Format(os.Stdout, batch); err != nil {
+			return fmt.Errorf("error writing %s output: %w", o.format, err)
+		}
+		return nil
+	}
+
+	f, err := os.Create(o.path)
+	if err != nil {
+		return fmt.Errorf("error creating output file: %w", err)
+	}
+	err = o.f.Format(f, batch)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing %s output to %s: %w", o.format, o.path, err)
+	}
+	return nil
+}
+
+// singleBatch envuelve el resultado de un solo host para los formatos; err
+// marca el host como fallido
+func singleBatch(host string, result *models.Host, err error) *models.BatchResult {
+	entry := models.HostResult{Host: host, Result: result}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.RateLimited = client.IsRateLimited(err)
+	}
+	return &models.BatchResult{Results: []models.HostResult{entry}}
+}
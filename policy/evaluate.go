@@ -0,0 +1,203 @@
+package policy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// Finding es el resultado de evaluar una regla sobre un endpoint
+type Finding struct {
+	RuleID   string   `json:"ruleId"`
+	Type     string   `json:"type"`
+	Severity Severity `json:"severity"`
+	Endpoint string   `json:"endpoint"`
+	Passed   bool     `json:"passed"`
+	Message  string   `json:"message"`
+}
+
+// Report agrupa los hallazgos de un host
+type Report struct {
+	Host     string    `json:"host"`
+	Findings []Finding `json:"findings"`
+}
+
+// Violations devuelve los hallazgos fallidos con severidad >= minimum
+func (r *Report) Violations(minimum Severity) []Finding {
+	var violations []Finding
+	for _, f := range r.Findings {
+		if !f.Passed && f.Severity.AtLeast(minimum) {
+			violations = append(violations, f)
+		}
+	}
+	return violations
+}
+
+// checkFunc evalúa una regla sobre un endpoint y devuelve si pasa y por qué
+type checkFunc func(rule *Rule, ep *models.Endpoint, now time.Time) (bool, string)
+
+// checks asocia cada tipo de regla con su comprobación
+var checks = map[string]checkFunc{
+	"min_grade":           checkMinGrade,
+	"forbidden_protocols": checkForbiddenProtocols,
+	"no_rc4":              checkNoRC4,
+	"forward_secrecy":     checkForwardSecrecy,
+	"min_key_strength":    checkMinKeyStrength,
+	"cert_min_days":       checkCertMinDays,
+	"ocsp_stapling":       checkOCSPStapling,
+	"clients_can_connect": checkClientsCanConnect,
+}
+
+// Evaluate aplica todas las reglas de la política a cada endpoint del host
+func Evaluate(p *Policy, host *models.Host) *Report {
+	now := time.Now()
+	report := &Report{Host: host.Host}
+
+	for i := range host.Endpoints {
+		ep := &host.Endpoints[i]
+		for j := range p.Rules {
+			rule := &p.Rules[j]
+			passed, msg := checks[rule.Type](rule, ep, now)
+			report.Findings = append(report.Findings, Finding{
+				RuleID:   rule.ID,
+				Type:     rule.Type,
+				Severity: rule.Severity,
+				Endpoint: ep.IPAddress,
+				Passed:   passed,
+				Message:  msg,
+			})
+		}
+	}
+
+	return report
+}
+
+// errNoDetails es el mensaje cuando el endpoint no trae detalles; la regla
+// falla para no dar por buena una configuración que no se pudo evaluar
+const errNoDetails = "no endpoint details available"
+
+func checkMinGrade(rule *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if MeetsGrade(ep.Grade, rule.Grade) {
+		return true, fmt.Sprintf("grade %s meets minimum %s", ep.Grade, rule.Grade)
+	}
+	if ep.Grade == "" {
+		return false, fmt.Sprintf("no grade available (minimum %s)", rule.Grade)
+	}
+	return false, fmt.Sprintf("grade %s is below minimum %s", ep.Grade, rule.Grade)
+}
+
+func checkForbiddenProtocols(rule *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+
+	var found []string
+	for _, proto := range ep.Details.Protocols {
+		name := proto.Name + " " + proto.Version
+		if slices.ContainsFunc(rule.Protocols, func(p string) bool { return strings.EqualFold(p, name) }) {
+			found = append(found, name)
+		}
+	}
+
+	if len(found) > 0 {
+		return false, fmt.Sprintf("forbidden protocols enabled: %s", strings.Join(found, ", "))
+	}
+	return true, "no forbidden protocols enabled"
+}
+
+func checkNoRC4(_ *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+	if ep.Details.SupportsRc4 {
+		return false, "RC4 cipher suites are supported"
+	}
+	return true, "RC4 is not supported"
+}
+
+func checkForwardSecrecy(rule *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+	if ep.Details.ForwardSecrecy&rule.Bits != rule.Bits {
+		return false, fmt.Sprintf("forward secrecy %d does not include required bits %d", ep.Details.ForwardSecrecy, rule.Bits)
+	}
+	return true, fmt.Sprintf("forward secrecy %d includes required bits %d", ep.Details.ForwardSecrecy, rule.Bits)
+}
+
+func checkMinKeyStrength(rule *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+	if ep.Details.Key.Strength < rule.Min {
+		return false, fmt.Sprintf("key strength %d is below %d", ep.Details.Key.Strength, rule.Min)
+	}
+	return true, fmt.Sprintf("key strength %d >= %d", ep.Details.Key.Strength, rule.Min)
+}
+
+func checkCertMinDays(rule *Rule, ep *models.Endpoint, now time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+
+	notAfter := time.UnixMilli(ep.Details.Cert.NotAfter)
+	left := notAfter.Sub(now)
+	// Se comparan duraciones: con días enteros, 30,9 días no serían "más de 30"
+	days := int(left.Hours() / 24)
+	if left <= time.Duration(rule.Min)*24*time.Hour {
+		return false, fmt.Sprintf("certificate expires in %d days (%s), must be more than %d", days, notAfter.Format("2006-01-02"), rule.Min)
+	}
+	return true, fmt.Sprintf("certificate expires in %d days (%s)", days, notAfter.Format("2006-01-02"))
+}
+
+func checkOCSPStapling(_ *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+	if !ep.Details.OcspStapling {
+		return false, "OCSP stapling is disabled"
+	}
+	return true, "OCSP stapling is enabled"
+}
+
+func checkClientsCanConnect(rule *Rule, ep *models.Endpoint, _ time.Time) (bool, string) {
+	if ep.Details == nil {
+		return false, errNoDetails
+	}
+	if ep.Details.Sims == nil || len(ep.Details.Sims.Results) == 0 {
+		return false, "no client simulation results available"
+	}
+
+	// Un cliente que no aparece en la simulación no se da por bueno
+	var failed, missing []string
+	for _, name := range rule.Clients {
+		found := false
+		for _, sim := range ep.Details.Sims.Results {
+			if !sim.Client.Matches(name) {
+				continue
+			}
+			found = true
+			if !sim.Connected() {
+				failed = append(failed, sim.Client.String())
+			}
+		}
+		if !found {
+			missing = append(missing, name)
+		}
+	}
+
+	var problems []string
+	if len(failed) > 0 {
+		problems = append(problems, "handshake fails for "+strings.Join(failed, ", "))
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "not simulated: "+strings.Join(missing, ", "))
+	}
+	if len(problems) > 0 {
+		return false, strings.Join(problems, "; ")
+	}
+	return true, fmt.Sprintf("all %d clients can connect", len(rule.Clients))
+}
@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+
+	"NebulaChallenge/models"
+)
+
+// gradeScale ordena los grados de SSL Labs de mejor a peor.
+// T (certificado no confiable) y M (nombre no coincide) son peores que F.
+var gradeScale = []string{"A+", "A", "A-", "B", "C", "D", "E", "F", "T", "M"}
+
+// GradeRank devuelve la posición del grado en la escala (0 = A+) o -1 si no
+// es un grado conocido
+func GradeRank(grade string) int {
+	grade = strings.ToUpper(strings.TrimSpace(grade))
+	for i, g := range gradeScale {
+		if g == grade {
+			return i
+		}
+	}
+	return -1
+}
+
+// GradeCount devuelve el número de grados de la escala
+func GradeCount() int {
+	return len(gradeScale)
+}
+
+// ValidGrade indica si grade pertenece a la escala
+func ValidGrade(grade string) bool {
+	return GradeRank(grade) >= 0
+}
+
+// MeetsGrade indica si grade es igual o mejor que minimum. Un grado vacío o
+// desconocido nunca cumple.
+func MeetsGrade(grade, minimum string) bool {
+	rank, minRank := GradeRank(grade), GradeRank(minimum)
+	return rank >= 0 && minRank >= 0 && rank <= minRank
+}
+
+// GradeFailures devuelve un mensaje por cada endpoint cuyo grado no alcanza
+// minimum. Con ignoreTrust se compara GradeTrustIgnored (si existe) en lugar
+// de Grade, útil para hosts internos con una CA privada.
+func GradeFailures(host *models.Host, minimum string, ignoreTrust bool) []string {
+	var failures []string
+	for _, ep := range host.Endpoints {
+		grade := ep.Grade
+		if ignoreTrust && ep.GradeTrustIgnored != "" {
+			grade = ep.GradeTrustIgnored
+		}
+
+		if MeetsGrade(grade, minimum) {
+			continue
+		}
+		if grade == "" {
+			grade = "none"
+		}
+		failures = append(failures, fmt.Sprintf("%s %s: grade %s is below %s", host.Host, ep.IPAddress, grade, minimum))
+	}
+	return failures
+}
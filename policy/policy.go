@@ -0,0 +1,164 @@
+package policy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity es la gravedad de una regla
+type Severity string
+
+// Niveles de severidad, de menor a mayor
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+)
+
+var severityOrder = []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// ParseSeverity valida un nivel de severidad
+func ParseSeverity(s string) (Severity, error) {
+	for _, sev := range severityOrder {
+		if string(sev) == strings.ToLower(s) {
+			return sev, nil
+		}
+	}
+	return "", fmt.Errorf("unknown severity %q (use low, medium, high or critical)", s)
+}
+
+// AtLeast indica si s es igual o más grave que other
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() >= other.rank()
+}
+
+func (s Severity) rank() int {
+	for i, sev := range severityOrder {
+		if sev == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// Policy es un conjunto de reglas que debe cumplir cada endpoint
+type Policy struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule es una regla declarativa. Type elige la comprobación y el resto de
+// campos son sus parámetros:
+//
+//	min_grade            grade: grado mínimo (p. ej. "A-")
+//	forbidden_protocols  protocols: lista como "TLS 1.0", "SSL 3.0"
+//	no_rc4               sin parámetros
+//	forward_secrecy      bits: bits de forwardSecrecy exigidos (por defecto 4)
+//	min_key_strength     min: fuerza mínima de la clave en bits
+//	cert_min_days        min: días mínimos hasta el vencimiento del certificado
+//	ocsp_stapling        sin parámetros
+//	clients_can_connect  clients: clientes simulados que deben conectar, como
+//	                     "Android 4.4.2" o "IE 11 / Win 7" ("IE 11" = todas
+//	                     las plataformas)
+type Rule struct {
+	ID          string   `json:"id" yaml:"id"`
+	Type        string   `json:"type" yaml:"type"`
+	Severity    Severity `json:"severity" yaml:"severity"`
+	Description string   `json:"description,omitempty" yaml:"description,omitempty"`
+
+	Grade     string   `json:"grade,omitempty" yaml:"grade,omitempty"`
+	Protocols []string `json:"protocols,omitempty" yaml:"protocols,omitempty"`
+	Min       int      `json:"min,omitempty" yaml:"min,omitempty"`
+	Bits      int      `json:"bits,omitempty" yaml:"bits,omitempty"`
+	Clients   []string `json:"clients,omitempty" yaml:"clients,omitempty"`
+}
+
+// Load lee una política desde un archivo YAML (.yaml, .yml) o JSON
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading policy: %w", err)
+	}
+
+	// Los campos desconocidos son un error: una clave mal escrita no debe
+	// dejar una regla sin efecto en silencio. Un archivo vacío llega a
+	// validate como política sin reglas.
+	var p Policy
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&p)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&p)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("error parsing policy %s: %w", path, err)
+	}
+
+	if err := p.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy %s: %w", path, err)
+	}
+
+	return &p, nil
+}
+
+// validate completa valores por defecto y verifica los parámetros
+func (p *Policy) validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("policy has no rules")
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+
+		if _, ok := checks[rule.Type]; !ok {
+			return fmt.Errorf("rule %d: unknown type %q", i+1, rule.Type)
+		}
+		if rule.ID == "" {
+			rule.ID = rule.Type
+		}
+
+		if rule.Severity == "" {
+			rule.Severity = SeverityHigh
+		}
+		if _, err := ParseSeverity(string(rule.Severity)); err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+
+		switch rule.Type {
+		case "min_grade":
+			if !ValidGrade(rule.Grade) {
+				return fmt.Errorf("rule %s: invalid grade %q", rule.ID, rule.Grade)
+			}
+		case "forbidden_protocols":
+			if len(rule.Protocols) == 0 {
+				return fmt.Errorf("rule %s: protocols is required", rule.ID)
+			}
+		case "clients_can_connect":
+			if len(rule.Clients) == 0 {
+				return fmt.Errorf("rule %s: clients is required", rule.ID)
+			}
+		case "min_key_strength", "cert_min_days":
+			if rule.Min <= 0 {
+				return fmt.Errorf("rule %s: min must be positive", rule.ID)
+			}
+		case "forward_secrecy":
+			if rule.Bits == 0 {
+				rule.Bits = 4
+			}
+		}
+	}
+
+	return nil
+}
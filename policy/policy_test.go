@@ -0,0 +1,218 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+func TestMeetsGrade(t *testing.T) {
+	tests := []struct {
+		grade, minimum string
+		want           bool
+	}{
+		{"A+", "A", true},
+		{"A", "A", true},
+		{"a-", "A", false},
+		{"B", "A-", false},
+		{"F", "F", true},
+		{"T", "F", false},
+		{"M", "T", false},
+		{"", "F", false},
+		{"Z", "F", false},
+		{"A", "Z", false},
+	}
+
+	for _, tt := range tests {
+		if got := MeetsGrade(tt.grade, tt.minimum); got != tt.want {
+			t.Errorf("MeetsGrade(%q, %q) = %v, want %v", tt.grade, tt.minimum, got, tt.want)
+		}
+	}
+}
+
+func TestGradeFailures(t *testing.T) {
+	host := &models.Host{
+		Host: "example.com",
+		Endpoints: []models.Endpoint{
+			{IPAddress: "192.0.2.1", Grade: "A"},
+			{IPAddress: "192.0.2.2", Grade: "T", GradeTrustIgnored: "A"},
+			{IPAddress: "192.0.2.3"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		ignoreTrust bool
+		want        []string
+	}{
+		{"trust", false, []string{
+			"example.com 192.0.2.2: grade T is below A-",
+			"example.com 192.0.2.3: grade none is below A-",
+		}},
+		{"ignore trust", true, []string{
+			"example.com 192.0.2.3: grade none is below A-",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GradeFailures(host, "A-", tt.ignoreTrust)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("GradeFailures() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Severity
+		wantErr bool
+	}{
+		{"low", SeverityLow, false},
+		{"HIGH", SeverityHigh, false},
+		{"Critical", SeverityCritical, false},
+		{"urgent", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSeverity(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseSeverity(%q) = %q, %v; want %q, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if !SeverityHigh.AtLeast(SeverityMedium) || !SeverityHigh.AtLeast(SeverityHigh) || SeverityLow.AtLeast(SeverityMedium) {
+		t.Error("AtLeast does not follow low < medium < high < critical")
+	}
+}
+
+func TestCheckCertMinDays(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	rule := &Rule{Type: "cert_min_days", Min: 30}
+
+	tests := []struct {
+		name     string
+		left     time.Duration
+		want     bool
+		contains string
+	}{
+		{"well ahead", 90 * day, true, "expires in 90 days"},
+		{"just over the minimum", 30*day + time.Hour, true, "expires in 30 days"},
+		{"exactly the minimum", 30 * day, false, "must be more than 30"},
+		{"just under the minimum", 30*day - time.Hour, false, "expires in 29 days"},
+		{"expired", -day, false, "must be more than 30"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ep := &models.Endpoint{Details: &models.EndpointDetails{
+				Cert: models.Cert{NotAfter: now.Add(tt.left).UnixMilli()},
+			}}
+			passed, msg := checkCertMinDays(rule, ep, now)
+			if passed != tt.want || !strings.Contains(msg, tt.contains) {
+				t.Errorf("checkCertMinDays() = %v, %q; want %v, containing %q", passed, msg, tt.want, tt.contains)
+			}
+		})
+	}
+
+	if passed, msg := checkCertMinDays(rule, &models.Endpoint{}, now); passed || msg != errNoDetails {
+		t.Errorf("checkCertMinDays() without details = %v, %q", passed, msg)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	p := &Policy{Rules: []Rule{
+		{ID: "grade", Type: "min_grade", Severity: SeverityHigh, Grade: "A-"},
+		{ID: "protocols", Type: "forbidden_protocols", Severity: SeverityMedium, Protocols: []string{"tls 1.0"}},
+		{ID: "rc4", Type: "no_rc4", Severity: SeverityLow},
+	}}
+	host := &models.Host{
+		Host: "example.com",
+		Endpoints: []models.Endpoint{
+			{IPAddress: "192.0.2.1", Grade: "A", Details: &models.EndpointDetails{
+				Protocols: []models.Protocol{{Name: "TLS", Version: "1.2"}},
+			}},
+			{IPAddress: "192.0.2.2", Grade: "B", Details: &models.EndpointDetails{
+				Protocols:   []models.Protocol{{Name: "TLS", Version: "1.0"}, {Name: "TLS", Version: "1.2"}},
+				SupportsRc4: true,
+			}},
+		},
+	}
+
+	report := Evaluate(p, host)
+	if len(report.Findings) != 6 {
+		t.Fatalf("got %d findings, want 6", len(report.Findings))
+	}
+
+	tests := []struct {
+		minimum Severity
+		want    []string
+	}{
+		{SeverityLow, []string{"192.0.2.2 grade", "192.0.2.2 protocols", "192.0.2.2 rc4"}},
+		{SeverityMedium, []string{"192.0.2.2 grade", "192.0.2.2 protocols"}},
+		{SeverityCritical, nil},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, f := range report.Violations(tt.minimum) {
+			got = append(got, f.Endpoint+" "+f.RuleID)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Violations(%s) = %q, want %q", tt.minimum, got, tt.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr string
+	}{
+		{"yaml", "policy.yaml", "rules:\n  - type: min_grade\n    grade: A\n", ""},
+		{"json", "policy.json", `{"rules": [{"type": "cert_min_days", "min": 30, "severity": "low"}]}`, ""},
+		{"unknown yaml field", "policy.yml", "rules:\n  - type: min_grade\n    grde: A\n", "error parsing policy"},
+		{"unknown json field", "policy.json", `{"rules": [{"type": "no_rc4", "sevrity": "low"}]}`, "error parsing policy"},
+		{"empty", "policy.yaml", "", "policy has no rules"},
+		{"unknown type", "policy.yaml", "rules:\n  - type: no_sslv2\n", `unknown type "no_sslv2"`},
+		{"invalid grade", "policy.yaml", "rules:\n  - type: min_grade\n    grade: G\n", `invalid grade "G"`},
+		{"missing min", "policy.yaml", "rules:\n  - type: cert_min_days\n", "min must be positive"},
+		{"invalid severity", "policy.yaml", "rules:\n  - type: no_rc4\n    severity: urgent\n", "unknown severity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			p, err := Load(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			// validate completa el ID y la severidad por defecto
+			rule := p.Rules[0]
+			if rule.ID != rule.Type || rule.Severity == "" {
+				t.Errorf("rule defaults not applied: %+v", rule)
+			}
+		})
+	}
+}
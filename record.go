@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/metrics"
+	"NebulaChallenge/models"
+	"NebulaChallenge/storage"
+)
+
+// recorder persiste los resultados terminados: el historial (--history-dir),
+// el archivo de métricas (--metrics-file) y el NDJSON (--ndjson). Un fallo
+// solo se informa: no debe cambiar el resultado del análisis.
+type recorder struct {
+	history     *storage.Store
+	metricsFile string
+	ndjson      io.Writer // nil = sin NDJSON; se escribe con stream
+}
+
+// stream escribe el host en el NDJSON en cuanto termina, sin esperar al
+// resto del batch. Los hosts que fallaron se escriben con Status ERROR
+// (HostResult.Report).
+func (r recorder) stream(host *models.Host) {
+	if r.ndjson == nil || (host.Status != "READY" && host.Status != "ERROR") {
+		return
+	}
+	if err := formatter.WriteNDJSON(r.ndjson, host); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
+
+func (r recorder) record(hosts ...*models.Host) {
+	var completed []*models.Host
+	for _, host := range hosts {
+		if host.Status == "READY" {
+			completed = append(completed, host)
+		}
+	}
+
+	if r.history != nil {
+		for _, host := range completed {
+			if _, err := r.history.Save(host); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: could not save %s to history: %v\n", host.Host, err)
+			}
+		}
+	}
+
+	if r.metricsFile != "" {
+		if err := metrics.WriteFile(r.metricsFile, completed); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
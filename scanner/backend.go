@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+
+	"NebulaChallenge/models"
+)
+
+// DefaultPort es el puerto que se analiza si la petición no indica otro
+const DefaultPort = 443
+
+// Name identifica al backend local
+func (s *Scanner) Name() string {
+	return "local"
+}
+
+// Start ejecuta el análisis completo de forma síncrona: el estado que
+// devuelve ya es el resultado final (READY o ERROR), así que el analizador
+// no llega a llamar a Poll y no hace falta guardar nada entre llamadas.
+func (s *Scanner) Start(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	port := req.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+
+	result, err := s.Scan(ctx, req.Host, port)
+	if err != nil && (result == nil || result.Status != "ERROR") {
+		return result, err
+	}
+	return result, nil
+}
+
+// Poll no tiene nada que consultar: Start solo devuelve análisis terminados
+func (s *Scanner) Poll(ctx context.Context, req models.ScanRequest) (*models.Host, error) {
+	return nil, fmt.Errorf("local analysis of %s is not in progress", req.Target())
+}
+
+// Result devuelve el resultado de Start, que recibe como último estado
+func (s *Scanner) Result(ctx context.Context, req models.ScanRequest, status *models.Host) (*models.Host, error) {
+	return status, nil
+}
@@ -0,0 +1,217 @@
+package scanner
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// applyCertificates completa Cert, Key y Chain a partir de la cadena
+// enviada por el servidor
+func applyCertificates(details *models.EndpointDetails, certs []*x509.Certificate, host string, roots *x509.CertPool, now time.Time) {
+	if len(certs) == 0 {
+		return
+	}
+
+	leaf := certs[0]
+	alg, size, strength := keyInfo(leaf)
+
+	details.Cert = models.Cert{
+		Subject:       leaf.Subject.String(),
+		SerialNumber:  fmt.Sprintf("%x", leaf.SerialNumber),
+		CommonNames:   []string{leaf.Subject.CommonName},
+		AltNames:      altNames(leaf),
+		NotBefore:     leaf.NotBefore.UnixMilli(),
+		NotAfter:      leaf.NotAfter.UnixMilli(),
+		IssuerSubject: leaf.Issuer.String(),
+		IssuerLabel:   leaf.Issuer.CommonName,
+		SigAlg:        leaf.SignatureAlgorithm.String(),
+		CrlURIs:       leaf.CRLDistributionPoints,
+		OcspURIs:      leaf.OCSPServer,
+		Issues:        certIssues(certs, host, roots, now),
+	}
+
+	details.Key = models.Key{
+		Alg:      alg,
+		Size:     size,
+		Strength: strength,
+	}
+
+	details.Chain = models.Chain{Issues: chainIssues(certs, roots)}
+	for _, cert := range certs {
+		alg, size, strength := keyInfo(cert)
+		details.Chain.Certs = append(details.Chain.Certs, models.ChainCert{
+			Subject:       cert.Subject.String(),
+			Label:         cert.Subject.CommonName,
+			NotBefore:     cert.NotBefore.UnixMilli(),
+			NotAfter:      cert.NotAfter.UnixMilli(),
+			IssuerSubject: cert.Issuer.String(),
+			IssuerLabel:   cert.Issuer.CommonName,
+			SigAlg:        cert.SignatureAlgorithm.String(),
+			Issues:        certTimeIssues(cert, now),
+			KeyAlg:        alg,
+			KeySize:       size,
+			KeyStrength:   strength,
+			Raw:           string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})),
+		})
+	}
+}
+
+// keyInfo devuelve algoritmo, tamaño y fuerza equivalente RSA de la clave,
+// con el mismo criterio que SSL Labs
+func keyInfo(cert *x509.Certificate) (alg string, size, strength int) {
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		size = key.N.BitLen()
+		return "RSA", size, size
+	case *ecdsa.PublicKey:
+		size = key.Curve.Params().BitSize
+		return "EC", size, ecStrength(size)
+	case ed25519.PublicKey:
+		return "Ed25519", 256, ecStrength(256)
+	default:
+		return cert.PublicKeyAlgorithm.String(), 0, 0
+	}
+}
+
+// ecStrength convierte el tamaño de una curva a su equivalente RSA
+func ecStrength(bits int) int {
+	switch {
+	case bits >= 512:
+		return 15360
+	case bits >= 384:
+		return 7680
+	case bits >= 256:
+		return 3072
+	case bits >= 224:
+		return 2048
+	default:
+		return 1024
+	}
+}
+
+// altNames junta los SAN DNS e IP del certificado
+func altNames(cert *x509.Certificate) []string {
+	names := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
+// certTimeIssues devuelve los bits de validez temporal del certificado
+func certTimeIssues(cert *x509.Certificate, now time.Time) int {
+	issues := 0
+	if now.Before(cert.NotBefore) {
+		issues |= models.CertIssueNotBefore
+	}
+	if now.After(cert.NotAfter) {
+		issues |= models.CertIssueNotAfter
+	}
+	return issues
+}
+
+// certIssues calcula el bitmask Cert.Issues del certificado hoja
+func certIssues(certs []*x509.Certificate, host string, roots *x509.CertPool, now time.Time) int {
+	leaf := certs[0]
+	issues := certTimeIssues(leaf, now)
+
+	if net.ParseIP(host) == nil || len(leaf.IPAddresses) > 0 {
+		if err := leaf.VerifyHostname(host); err != nil {
+			issues |= models.CertIssueHostnameMismatch
+		}
+	}
+
+	if isSelfSigned(leaf) {
+		issues |= models.CertIssueSelfSigned
+	}
+
+	switch leaf.SignatureAlgorithm {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.ECDSAWithSHA1, x509.DSAWithSHA1:
+		issues |= models.CertIssueInsecureSignature
+	}
+
+	if !isTrusted(certs, roots) {
+		issues |= models.CertIssueNoChainOfTrust
+	}
+
+	return issues
+}
+
+// chainIssues calcula el bitmask Chain.Issues de la cadena enviada
+func chainIssues(certs []*x509.Certificate, roots *x509.CertPool) int {
+	issues := 0
+
+	for i := 0; i < len(certs)-1; i++ {
+		if certs[i].CheckSignatureFrom(certs[i+1]) == nil {
+			continue
+		}
+		// El emisor está en la cadena pero en otra posición, o no está
+		if issuedByAny(certs[i], certs) {
+			issues |= models.ChainIssueIncorrectOrder
+		} else {
+			issues |= models.ChainIssueUnrelated
+		}
+	}
+
+	if len(certs) > 1 && isSelfSigned(certs[len(certs)-1]) {
+		issues |= models.ChainIssueSelfSignedRoot
+	}
+
+	if !isTrusted(certs, roots) {
+		last := certs[len(certs)-1]
+		if !isSelfSigned(last) && len(last.IssuingCertificateURL) > 0 {
+			issues |= models.ChainIssueIncomplete
+		} else {
+			issues |= models.ChainIssueUnverifiable
+		}
+	}
+
+	return issues
+}
+
+// isTrusted verifica la cadena contra las raíces, ignorando fechas y
+// nombre (que se reportan con sus propios bits)
+func isTrusted(certs []*x509.Certificate, roots *x509.CertPool) bool {
+	leaf := certs[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   leaf.NotBefore.Add(leaf.NotAfter.Sub(leaf.NotBefore) / 2),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+
+	var hostErr x509.HostnameError
+	return err == nil || errors.As(err, &hostErr)
+}
+
+// isSelfSigned indica si el certificado está firmado por sí mismo
+func isSelfSigned(cert *x509.Certificate) bool {
+	return strings.EqualFold(cert.Subject.String(), cert.Issuer.String()) &&
+		cert.CheckSignatureFrom(cert) == nil
+}
+
+// issuedByAny indica si algún otro certificado de la lista firmó cert
+func issuedByAny(cert *x509.Certificate, certs []*x509.Certificate) bool {
+	for _, other := range certs {
+		if other != cert && cert.CheckSignatureFrom(other) == nil {
+			return true
+		}
+	}
+	return false
+}
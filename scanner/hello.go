@@ -0,0 +1,304 @@
+package scanner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Versiones de protocolo tal como viajan en el ClientHello
+const (
+	versionSSL30 uint16 = 0x0300
+	versionTLS10 uint16 = 0x0301
+	versionTLS11 uint16 = 0x0302
+	versionTLS12 uint16 = 0x0303
+	versionTLS13 uint16 = 0x0304
+)
+
+// Tipos de registro y de mensaje de handshake usados en el sondeo
+const (
+	recordTypeAlert     = 21
+	recordTypeHandshake = 22
+
+	handshakeTypeClientHello = 1
+	handshakeTypeServerHello = 2
+)
+
+// Extensiones TLS que incluye el ClientHello
+const (
+	extServerName          = 0x0000
+	extSupportedGroups     = 0x000a
+	extECPointFormats      = 0x000b
+	extSignatureAlgorithms = 0x000d
+	extSupportedVersions   = 0x002b
+	extKeyShare            = 0x0033
+)
+
+// emptyRenegotiationInfoSCSV señala soporte de renegociación segura
+const emptyRenegotiationInfoSCSV uint16 = 0x00ff
+
+// groupX25519 es el grupo usado para el key share de TLS 1.3
+const groupX25519 uint16 = 0x001d
+
+// errHandshakeRejected indica que el servidor respondió con una alerta
+// o cerró la conexión en lugar de enviar un ServerHello
+var errHandshakeRejected = errors.New("handshake rejected")
+
+// serverHello contiene lo que interesa de la respuesta del servidor
+type serverHello struct {
+	Version     uint16
+	CipherSuite uint16
+}
+
+// probe envía un ClientHello con la versión y los suites indicados y
+// devuelve el ServerHello recibido
+func (s *Scanner) probe(ctx context.Context, addr, serverName string, version uint16, suites []uint16) (*serverHello, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	conn, err := s.dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	hello, err := buildClientHello(version, suites, serverName)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := conn.Write(hello); err != nil {
+		return nil, fmt.Errorf("error sending ClientHello: %w", err)
+	}
+
+	return readServerHello(conn)
+}
+
+// buildClientHello arma el registro TLS con un ClientHello
+func buildClientHello(version uint16, suites []uint16, serverName string) ([]byte, error) {
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, fmt.Errorf("error generating random: %w", err)
+	}
+
+	clientVersion := version
+	if version == versionTLS13 {
+		clientVersion = versionTLS12
+	}
+
+	var body []byte
+	body = binary.BigEndian.AppendUint16(body, clientVersion)
+	body = append(body, random...)
+
+	// TLS 1.3 usa un session id no vacío por compatibilidad con middleboxes
+	if version == versionTLS13 {
+		sessionID := make([]byte, 32)
+		if _, err := rand.Read(sessionID); err != nil {
+			return nil, fmt.Errorf("error generating session id: %w", err)
+		}
+		body = append(body, byte(len(sessionID)))
+		body = append(body, sessionID...)
+	} else {
+		body = append(body, 0)
+	}
+
+	offered := suites
+	if version != versionSSL30 {
+		offered = append(append([]uint16{}, suites...), emptyRenegotiationInfoSCSV)
+	}
+	body = binary.BigEndian.AppendUint16(body, uint16(2*len(offered)))
+	for _, id := range offered {
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+
+	// Solo compresión nula
+	body = append(body, 1, 0)
+
+	if version != versionSSL30 {
+		extensions, err := buildExtensions(version, serverName)
+		if err != nil {
+			return nil, err
+		}
+		body = binary.BigEndian.AppendUint16(body, uint16(len(extensions)))
+		body = append(body, extensions...)
+	}
+
+	handshake := []byte{handshakeTypeClientHello, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	handshake = append(handshake, body...)
+
+	recordVersion := versionTLS10
+	if version == versionSSL30 {
+		recordVersion = versionSSL30
+	}
+
+	record := []byte{recordTypeHandshake}
+	record = binary.BigEndian.AppendUint16(record, recordVersion)
+	record = binary.BigEndian.AppendUint16(record, uint16(len(handshake)))
+	return append(record, handshake...), nil
+}
+
+// buildExtensions arma las extensiones del ClientHello
+func buildExtensions(version uint16, serverName string) ([]byte, error) {
+	var ext []byte
+
+	// SNI (no se envía para direcciones IP)
+	if serverName != "" && net.ParseIP(serverName) == nil {
+		name := []byte(serverName)
+		var sni []byte
+		sni = binary.BigEndian.AppendUint16(sni, uint16(len(name)+3))
+		sni = append(sni, 0) // host_name
+		sni = binary.BigEndian.AppendUint16(sni, uint16(len(name)))
+		sni = append(sni, name...)
+		ext = appendExtension(ext, extServerName, sni)
+	}
+
+	// Curvas: x25519, secp256r1, secp384r1, secp521r1
+	groups := []uint16{groupX25519, 0x0017, 0x0018, 0x0019}
+	var groupsData []byte
+	groupsData = binary.BigEndian.AppendUint16(groupsData, uint16(2*len(groups)))
+	for _, g := range groups {
+		groupsData = binary.BigEndian.AppendUint16(groupsData, g)
+	}
+	ext = appendExtension(ext, extSupportedGroups, groupsData)
+
+	// Solo formato de punto sin comprimir
+	ext = appendExtension(ext, extECPointFormats, []byte{1, 0})
+
+	if version >= versionTLS12 {
+		algs := []uint16{0x0403, 0x0503, 0x0603, 0x0804, 0x0805, 0x0806, 0x0401, 0x0501, 0x0601, 0x0203, 0x0201}
+		var algsData []byte
+		algsData = binary.BigEndian.AppendUint16(algsData, uint16(2*len(algs)))
+		for _, a := range algs {
+			algsData = binary.BigEndian.AppendUint16(algsData, a)
+		}
+		ext = appendExtension(ext, extSignatureAlgorithms, algsData)
+	}
+
+	if version == versionTLS13 {
+		ext = appendExtension(ext, extSupportedVersions, []byte{2, 0x03, 0x04})
+
+		// Key share x25519 con bytes aleatorios: basta para recibir el
+		// ServerHello, el handshake no se completa
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("error generating key share: %w", err)
+		}
+		var share []byte
+		share = binary.BigEndian.AppendUint16(share, uint16(4+len(key)))
+		share = binary.BigEndian.AppendUint16(share, groupX25519)
+		share = binary.BigEndian.AppendUint16(share, uint16(len(key)))
+		share = append(share, key...)
+		ext = appendExtension(ext, extKeyShare, share)
+	}
+
+	return ext, nil
+}
+
+// appendExtension agrega una extensión con su tipo y longitud
+func appendExtension(ext []byte, extType uint16, data []byte) []byte {
+	ext = binary.BigEndian.AppendUint16(ext, extType)
+	ext = binary.BigEndian.AppendUint16(ext, uint16(len(data)))
+	return append(ext, data...)
+}
+
+// readServerHello lee registros hasta obtener el ServerHello completo
+func readServerHello(r io.Reader) (*serverHello, error) {
+	var handshake []byte
+
+	for {
+		header := make([]byte, 5)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, errHandshakeRejected
+		}
+
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return nil, errHandshakeRejected
+		}
+
+		switch header[0] {
+		case recordTypeAlert:
+			return nil, errHandshakeRejected
+		case recordTypeHandshake:
+			handshake = append(handshake, payload...)
+		default:
+			return nil, fmt.Errorf("unexpected TLS record type %d", header[0])
+		}
+
+		if len(handshake) < 4 {
+			continue
+		}
+		if handshake[0] != handshakeTypeServerHello {
+			return nil, fmt.Errorf("unexpected handshake message type %d", handshake[0])
+		}
+
+		msgLen := int(handshake[1])<<16 | int(handshake[2])<<8 | int(handshake[3])
+		if len(handshake) < 4+msgLen {
+			continue
+		}
+
+		return parseServerHello(handshake[4 : 4+msgLen])
+	}
+}
+
+// parseServerHello extrae la versión y el cipher suite elegidos
+func parseServerHello(msg []byte) (*serverHello, error) {
+	// version(2) + random(32) + session id length(1)
+	if len(msg) < 35 {
+		return nil, fmt.Errorf("ServerHello too short")
+	}
+
+	hello := &serverHello{Version: binary.BigEndian.Uint16(msg[0:2])}
+
+	pos := 34
+	sessionIDLen := int(msg[pos])
+	pos += 1 + sessionIDLen
+	if len(msg) < pos+3 {
+		return nil, fmt.Errorf("ServerHello too short")
+	}
+
+	hello.CipherSuite = binary.BigEndian.Uint16(msg[pos : pos+2])
+	pos += 3 // cipher suite + compresión
+
+	if len(msg) < pos+2 {
+		return hello, nil
+	}
+
+	// En TLS 1.3 la versión real viaja en la extensión supported_versions
+	extEnd := pos + 2 + int(binary.BigEndian.Uint16(msg[pos:pos+2]))
+	pos += 2
+	for pos+4 <= extEnd && extEnd <= len(msg) {
+		extType := binary.BigEndian.Uint16(msg[pos : pos+2])
+		extLen := int(binary.BigEndian.Uint16(msg[pos+2 : pos+4]))
+		pos += 4
+		if pos+extLen > extEnd {
+			break
+		}
+		if extType == extSupportedVersions && extLen == 2 {
+			hello.Version = binary.BigEndian.Uint16(msg[pos : pos+2])
+		}
+		pos += extLen
+	}
+
+	return hello, nil
+}
+
+// deadlineDialer es el subconjunto de net.Dialer que usa el scanner
+type deadlineDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// defaultDialer devuelve el dialer por defecto con el timeout indicado
+func defaultDialer(timeout time.Duration) deadlineDialer {
+	return &net.Dialer{Timeout: timeout}
+}
@@ -0,0 +1,24 @@
+package scanner
+
+import (
+	"crypto/x509"
+	"time"
+)
+
+// Option configura un Scanner en NewScanner
+type Option func(*Scanner)
+
+// WithTimeout define el timeout de cada conexión de sondeo
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *Scanner) {
+		s.timeout = timeout
+	}
+}
+
+// WithRootCAs define las CAs de confianza para validar la cadena, por
+// ejemplo la CA interna de la organización. Por defecto se usan las del sistema.
+func WithRootCAs(roots *x509.CertPool) Option {
+	return func(s *Scanner) {
+		s.roots = roots
+	}
+}
@@ -0,0 +1,337 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// EngineVersion identifica al scanner local en models.Host.EngineVersion
+const EngineVersion = "nebula-local/1.0"
+
+// DefaultTimeout es el timeout por defecto de cada conexión de sondeo
+const DefaultTimeout = 10 * time.Second
+
+// protocolVersions son las versiones que se sondean, de menor a mayor
+var protocolVersions = []struct {
+	ID      uint16
+	Name    string
+	Version string
+}{
+	{versionSSL30, "SSL", "3.0"},
+	{versionTLS10, "TLS", "1.0"},
+	{versionTLS11, "TLS", "1.1"},
+	{versionTLS12, "TLS", "1.2"},
+	{versionTLS13, "TLS", "1.3"},
+}
+
+// Scanner analiza TLS conectándose directamente al servidor, sin SSL Labs.
+// Sirve para hosts internos que SSL Labs no puede alcanzar.
+type Scanner struct {
+	timeout time.Duration
+	roots   *x509.CertPool
+	dialer  deadlineDialer
+}
+
+// NewScanner crea un scanner local
+func NewScanner(opts ...Option) *Scanner {
+	s := &Scanner{
+		timeout: DefaultTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.dialer = defaultDialer(s.timeout)
+	return s
+}
+
+// Scan analiza todas las IPs de host en el puerto indicado y devuelve el
+// resultado con el mismo modelo que SSL Labs
+func (s *Scanner) Scan(ctx context.Context, host string, port int) (*models.Host, error) {
+	start := time.Now()
+
+	ips, err := s.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Host{
+		Host:          host,
+		Port:          port,
+		Protocol:      "tls",
+		IsPublic:      false,
+		Status:        "IN_PROGRESS",
+		StartTime:     start.UnixMilli(),
+		EngineVersion: EngineVersion,
+	}
+
+	var lastErr error
+	failed := 0
+	for _, ip := range ips {
+		ep, err := s.scanEndpoint(ctx, host, ip, port, start)
+		if err != nil {
+			if ctx.Err() != nil {
+				return result, fmt.Errorf("scan cancelled: %w", ctx.Err())
+			}
+			lastErr = err
+			failed++
+			ep = &models.Endpoint{
+				IPAddress:     ip,
+				ServerName:    host,
+				StatusMessage: err.Error(),
+				Progress:      100,
+			}
+		}
+		result.Endpoints = append(result.Endpoints, *ep)
+	}
+
+	// Como en SSL Labs, el host falla solo si no se pudo analizar ningún endpoint
+	if failed == len(ips) {
+		result.Status = "ERROR"
+		result.StatusMessage = lastErr.Error()
+		return result, fmt.Errorf("scan failed: %w", lastErr)
+	}
+
+	result.Status = "READY"
+	result.TestTime = time.Now().UnixMilli()
+	return result, nil
+}
+
+// resolve devuelve las IPs del host (o el propio host si ya es una IP)
+func (s *Scanner) resolve(ctx context.Context, host string) ([]string, error) {
+	if net.ParseIP(host) != nil {
+		return []string{host}, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving %s: %w", host, err)
+	}
+
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	return ips, nil
+}
+
+// scanEndpoint sondea protocolos, suites y certificado de una IP
+func (s *Scanner) scanEndpoint(ctx context.Context, host, ip string, port int, hostStart time.Time) (*models.Endpoint, error) {
+	start := time.Now()
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
+
+	details := &models.EndpointDetails{
+		HostStartTime: hostStart.UnixMilli(),
+	}
+
+	// Protocolos y suites aceptados por versión, en orden de elección del servidor
+	accepted := make(map[uint16][]cipherSuite)
+	for _, pv := range protocolVersions {
+		candidates := legacySuites
+		if pv.ID == versionTLS13 {
+			candidates = tls13Suites
+		}
+
+		suites, err := s.enumerateSuites(ctx, addr, host, pv.ID, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if len(suites) == 0 {
+			continue
+		}
+
+		accepted[pv.ID] = suites
+		details.Protocols = append(details.Protocols, models.Protocol{
+			ID:      int(pv.ID),
+			Name:    pv.Name,
+			Version: pv.Version,
+		})
+	}
+
+	if len(details.Protocols) == 0 {
+		return nil, fmt.Errorf("no SSL/TLS protocol accepted by %s", addr)
+	}
+
+	// Lista de suites: primero los de la versión más alta
+	seen := make(map[uint16]bool)
+	for i := len(protocolVersions) - 1; i >= 0; i-- {
+		for _, suite := range accepted[protocolVersions[i].ID] {
+			if seen[suite.ID] {
+				continue
+			}
+			seen[suite.ID] = true
+			details.Suites.List = append(details.Suites.List, models.Suite{
+				ID:             int(suite.ID),
+				Name:           suite.Name,
+				CipherStrength: suite.Strength,
+			})
+		}
+	}
+
+	highest := details.Protocols[len(details.Protocols)-1].ID
+	preference, err := s.serverPreference(ctx, addr, host, uint16(highest), accepted[uint16(highest)])
+	if err != nil {
+		return nil, err
+	}
+	details.Suites.Preference = preference
+
+	applySuiteFlags(details, accepted, uint16(highest))
+
+	// Certificado y cadena con un handshake real
+	if err := s.inspectCertificates(ctx, addr, host, details); err != nil {
+		return nil, err
+	}
+
+	return &models.Endpoint{
+		IPAddress:     ip,
+		ServerName:    host,
+		StatusMessage: "Ready",
+		Progress:      100,
+		Duration:      int(time.Since(start).Milliseconds()),
+		Details:       details,
+	}, nil
+}
+
+// enumerateSuites ofrece todos los candidatos y va quitando el que elige el
+// servidor hasta que rechaza el handshake. El orden resultante es el orden
+// de elección del servidor.
+func (s *Scanner) enumerateSuites(ctx context.Context, addr, host string, version uint16, candidates []cipherSuite) ([]cipherSuite, error) {
+	remaining := slices.Clone(candidates)
+	var accepted []cipherSuite
+
+	for len(remaining) > 0 {
+		hello, err := s.probe(ctx, addr, host, version, suiteIDs(remaining))
+		if errors.Is(err, errHandshakeRejected) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// El servidor negoció otra versión: la pedida no está soportada
+		if hello.Version != version {
+			break
+		}
+
+		idx := slices.IndexFunc(remaining, func(cs cipherSuite) bool { return cs.ID == hello.CipherSuite })
+		if idx < 0 {
+			break
+		}
+
+		accepted = append(accepted, remaining[idx])
+		remaining = slices.Delete(remaining, idx, idx+1)
+	}
+
+	return accepted, nil
+}
+
+// serverPreference detecta si el servidor impone su orden de suites
+// ofreciendo los aceptados en orden inverso
+func (s *Scanner) serverPreference(ctx context.Context, addr, host string, version uint16, accepted []cipherSuite) (bool, error) {
+	if len(accepted) < 2 {
+		return true, nil
+	}
+
+	reversed := slices.Clone(accepted)
+	slices.Reverse(reversed)
+
+	hello, err := s.probe(ctx, addr, host, version, suiteIDs(reversed))
+	if errors.Is(err, errHandshakeRejected) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return hello.CipherSuite == accepted[0].ID, nil
+}
+
+// applySuiteFlags calcula RC4, forward secrecy y vulnerabilidades que se
+// deducen de los suites aceptados
+func applySuiteFlags(details *models.EndpointDetails, accepted map[uint16][]cipherSuite, highest uint16) {
+	allFS, anyFS, allRC4 := true, false, true
+
+	for version, suites := range accepted {
+		for _, suite := range suites {
+			if suite.forwardSecret() {
+				anyFS = true
+			} else {
+				allFS = false
+			}
+
+			if suite.isRC4() {
+				details.SupportsRc4 = true
+				if version >= versionTLS12 {
+					details.Rc4WithModern = true
+				}
+			} else {
+				allRC4 = false
+			}
+
+			if suite.isCBC() && version <= versionTLS10 {
+				details.VulnBeast = true
+			}
+			if suite.isCBC() && version == versionSSL30 {
+				details.Poodle = true
+			}
+			if suite.Export && suite.Kx == kxRSA {
+				details.Freak = true
+			}
+			if suite.Export && suite.Kx == kxDHE {
+				details.Logjam = true
+			}
+		}
+	}
+
+	details.Rc4Only = details.SupportsRc4 && allRC4
+
+	// Bits de forward secrecy como los reporta SSL Labs
+	if anyFS {
+		details.ForwardSecrecy |= 1
+	}
+	if preferred := accepted[highest]; len(preferred) > 0 && preferred[0].forwardSecret() {
+		details.ForwardSecrecy |= 2
+	}
+	if allFS {
+		details.ForwardSecrecy |= 4
+	}
+}
+
+// inspectCertificates completa Cert, Key, Chain y OCSP stapling con un
+// handshake de crypto/tls
+func (s *Scanner) inspectCertificates(ctx context.Context, addr, host string, details *models.EndpointDetails) error {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: s.timeout},
+		Config: &tls.Config{
+			ServerName:         host,
+			InsecureSkipVerify: true, // La validación se hace aparte para reportar los problemas
+			MinVersion:         tls.VersionTLS10,
+		},
+	}
+
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		// Sin un handshake que crypto/tls soporte no hay certificado que mostrar
+		return nil
+	}
+	defer conn.Close()
+
+	state := conn.(*tls.Conn).ConnectionState()
+	details.OcspStapling = len(state.OCSPResponse) > 0
+
+	applyCertificates(details, state.PeerCertificates, host, s.roots, time.Now())
+	return nil
+}
@@ -0,0 +1,122 @@
+package scanner
+
+import "strings"
+
+// Tipos de intercambio de claves del catálogo de cipher suites
+const (
+	kxRSA   = "RSA"
+	kxDHE   = "DHE"
+	kxECDHE = "ECDHE"
+	kxAnon  = "anon"
+	kxTLS13 = "TLS13"
+)
+
+// cipherSuite describe un cipher suite que el scanner sabe ofrecer
+type cipherSuite struct {
+	ID       uint16
+	Name     string
+	Strength int
+	Kx       string
+	Export   bool
+}
+
+// forwardSecret indica si el suite ofrece forward secrecy
+func (s cipherSuite) forwardSecret() bool {
+	return s.Kx == kxDHE || s.Kx == kxECDHE || s.Kx == kxTLS13
+}
+
+// isRC4 indica si el suite usa RC4
+func (s cipherSuite) isRC4() bool {
+	return strings.Contains(s.Name, "_RC4_")
+}
+
+// isCBC indica si el suite usa un cifrado en modo CBC
+func (s cipherSuite) isCBC() bool {
+	return strings.Contains(s.Name, "_CBC_")
+}
+
+// tls13Suites son los cipher suites definidos para TLS 1.3
+var tls13Suites = []cipherSuite{
+	{0x1301, "TLS_AES_128_GCM_SHA256", 128, kxTLS13, false},
+	{0x1302, "TLS_AES_256_GCM_SHA384", 256, kxTLS13, false},
+	{0x1303, "TLS_CHACHA20_POLY1305_SHA256", 256, kxTLS13, false},
+	{0x1304, "TLS_AES_128_CCM_SHA256", 128, kxTLS13, false},
+	{0x1305, "TLS_AES_128_CCM_8_SHA256", 128, kxTLS13, false},
+}
+
+// legacySuites son los cipher suites que se prueban en SSL 3.0 a TLS 1.2,
+// incluidos los débiles para poder detectarlos
+var legacySuites = []cipherSuite{
+	// ECDHE
+	{0xc02b, "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", 128, kxECDHE, false},
+	{0xc02c, "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384", 256, kxECDHE, false},
+	{0xc02f, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", 128, kxECDHE, false},
+	{0xc030, "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", 256, kxECDHE, false},
+	{0xcca8, "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256", 256, kxECDHE, false},
+	{0xcca9, "TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256", 256, kxECDHE, false},
+	{0xc023, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256", 128, kxECDHE, false},
+	{0xc024, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA384", 256, kxECDHE, false},
+	{0xc027, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256", 128, kxECDHE, false},
+	{0xc028, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA384", 256, kxECDHE, false},
+	{0xc009, "TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA", 128, kxECDHE, false},
+	{0xc00a, "TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA", 256, kxECDHE, false},
+	{0xc013, "TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA", 128, kxECDHE, false},
+	{0xc014, "TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA", 256, kxECDHE, false},
+	{0xc008, "TLS_ECDHE_ECDSA_WITH_3DES_EDE_CBC_SHA", 112, kxECDHE, false},
+	{0xc012, "TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA", 112, kxECDHE, false},
+	{0xc007, "TLS_ECDHE_ECDSA_WITH_RC4_128_SHA", 128, kxECDHE, false},
+	{0xc011, "TLS_ECDHE_RSA_WITH_RC4_128_SHA", 128, kxECDHE, false},
+
+	// DHE
+	{0x009e, "TLS_DHE_RSA_WITH_AES_128_GCM_SHA256", 128, kxDHE, false},
+	{0x009f, "TLS_DHE_RSA_WITH_AES_256_GCM_SHA384", 256, kxDHE, false},
+	{0xccaa, "TLS_DHE_RSA_WITH_CHACHA20_POLY1305_SHA256", 256, kxDHE, false},
+	{0x0067, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA256", 128, kxDHE, false},
+	{0x006b, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA256", 256, kxDHE, false},
+	{0x0033, "TLS_DHE_RSA_WITH_AES_128_CBC_SHA", 128, kxDHE, false},
+	{0x0039, "TLS_DHE_RSA_WITH_AES_256_CBC_SHA", 256, kxDHE, false},
+	{0x0045, "TLS_DHE_RSA_WITH_CAMELLIA_128_CBC_SHA", 128, kxDHE, false},
+	{0x0088, "TLS_DHE_RSA_WITH_CAMELLIA_256_CBC_SHA", 256, kxDHE, false},
+	{0x0016, "TLS_DHE_RSA_WITH_3DES_EDE_CBC_SHA", 112, kxDHE, false},
+	{0x0015, "TLS_DHE_RSA_WITH_DES_CBC_SHA", 56, kxDHE, false},
+	{0x0014, "TLS_DHE_RSA_EXPORT_WITH_DES40_CBC_SHA", 40, kxDHE, true},
+	{0x0011, "TLS_DHE_DSS_EXPORT_WITH_DES40_CBC_SHA", 40, kxDHE, true},
+
+	// RSA
+	{0x009c, "TLS_RSA_WITH_AES_128_GCM_SHA256", 128, kxRSA, false},
+	{0x009d, "TLS_RSA_WITH_AES_256_GCM_SHA384", 256, kxRSA, false},
+	{0x003c, "TLS_RSA_WITH_AES_128_CBC_SHA256", 128, kxRSA, false},
+	{0x003d, "TLS_RSA_WITH_AES_256_CBC_SHA256", 256, kxRSA, false},
+	{0x002f, "TLS_RSA_WITH_AES_128_CBC_SHA", 128, kxRSA, false},
+	{0x0035, "TLS_RSA_WITH_AES_256_CBC_SHA", 256, kxRSA, false},
+	{0x0041, "TLS_RSA_WITH_CAMELLIA_128_CBC_SHA", 128, kxRSA, false},
+	{0x0084, "TLS_RSA_WITH_CAMELLIA_256_CBC_SHA", 256, kxRSA, false},
+	{0x0096, "TLS_RSA_WITH_SEED_CBC_SHA", 128, kxRSA, false},
+	{0x0007, "TLS_RSA_WITH_IDEA_CBC_SHA", 128, kxRSA, false},
+	{0x000a, "TLS_RSA_WITH_3DES_EDE_CBC_SHA", 112, kxRSA, false},
+	{0x0005, "TLS_RSA_WITH_RC4_128_SHA", 128, kxRSA, false},
+	{0x0004, "TLS_RSA_WITH_RC4_128_MD5", 128, kxRSA, false},
+	{0x0009, "TLS_RSA_WITH_DES_CBC_SHA", 56, kxRSA, false},
+	{0x0062, "TLS_RSA_EXPORT1024_WITH_DES_CBC_SHA", 56, kxRSA, true},
+	{0x0064, "TLS_RSA_EXPORT1024_WITH_RC4_56_SHA", 56, kxRSA, true},
+	{0x0008, "TLS_RSA_EXPORT_WITH_DES40_CBC_SHA", 40, kxRSA, true},
+	{0x0006, "TLS_RSA_EXPORT_WITH_RC2_CBC_40_MD5", 40, kxRSA, true},
+	{0x0003, "TLS_RSA_EXPORT_WITH_RC4_40_MD5", 40, kxRSA, true},
+	{0x003b, "TLS_RSA_WITH_NULL_SHA256", 0, kxRSA, false},
+	{0x0002, "TLS_RSA_WITH_NULL_SHA", 0, kxRSA, false},
+	{0x0001, "TLS_RSA_WITH_NULL_MD5", 0, kxRSA, false},
+
+	// Anónimos
+	{0x0018, "TLS_DH_anon_WITH_RC4_128_MD5", 128, kxAnon, false},
+	{0x0034, "TLS_DH_anon_WITH_AES_128_CBC_SHA", 128, kxAnon, false},
+	{0xc018, "TLS_ECDH_anon_WITH_AES_128_CBC_SHA", 128, kxAnon, false},
+}
+
+// suiteIDs devuelve los IDs de una lista de suites
+func suiteIDs(suites []cipherSuite) []uint16 {
+	ids := make([]uint16, len(suites))
+	for i, s := range suites {
+		ids[i] = s.ID
+	}
+	return ids
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"NebulaChallenge/analyzer"
+	"NebulaChallenge/server"
+	"NebulaChallenge/storage"
+)
+
+// runServe implementa el comando "serve": expone los análisis como una API
+// REST hasta recibir SIGINT o SIGTERM
+func runServe(args []string) int {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	workers := fs.Int("workers", server.DefaultWorkers, "Scans that run at the same time")
+	queueSize := fs.Int("queue-size", server.DefaultQueueSize, "Maximum pending scans")
+	jobTTL := fs.Duration("job-ttl", server.DefaultJobTTL, "How long finished scans are kept in memory")
+	shutdownTimeout := fs.Duration("shutdown-timeout", server.DefaultShutdownTimeout, "How long to wait for running scans on shutdown")
+	maxWait := fs.Duration("max-wait", 0, "Maximum total wait per host (0 = no limit)")
+	historyDir := fs.String("history-dir", storage.DefaultDir(), "Directory where completed assessments are saved")
+	noHistory := fs.Bool("no-history", false, "Do not save completed assessments to the history")
+	verbose := fs.Bool("verbose", false, "Log retries, rate-limit waits and polling to stderr")
+
+	var engines engineConfig
+	engines.register(fs)
+
+	if fs.Parse(args) != nil {
+		return exitError
+	}
+
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	engines.logger = logger
+	backend, err := engines.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	opts := []server.Option{
+		server.WithAnalyzerOptions(analyzer.WithMaxWait(*maxWait), analyzer.WithLogger(logger)),
+		server.WithWorkers(*workers),
+		server.WithQueueSize(*queueSize),
+		server.WithJobTTL(*jobTTL),
+		server.WithShutdownTimeout(*shutdownTimeout),
+		server.WithLogger(logger),
+	}
+	if !*noHistory {
+		store, err := storage.Open(*historyDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: history disabled: %v\n", err)
+		} else {
+			opts = append(opts, server.WithHistory(store))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = server.New(backend, opts...).ListenAndServe(ctx, *addr)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
@@ -0,0 +1,257 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"NebulaChallenge/models"
+	"NebulaChallenge/utils"
+)
+
+// Estados de un trabajo
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobDone      = "done"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// ErrQueueFull indica que la cola no admite más trabajos
+var ErrQueueFull = errors.New("scan queue is full")
+
+// ErrShuttingDown indica que el servidor ya no acepta trabajos
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// ErrTargetActive indica que ya hay un trabajo encolado o en curso para el
+// mismo host y puerto. Los backends guardan el estado de cada análisis por
+// target, así que dos trabajos simultáneos se pisarían.
+var ErrTargetActive = errors.New("a scan of this host is already queued or running")
+
+// ScanRequest es el cuerpo de POST /scans
+type ScanRequest struct {
+	Host      string `json:"host"`
+	Publish   bool   `json:"publish"`
+	FromCache bool   `json:"fromCache"`
+	MaxAge    int    `json:"maxAge,omitempty"` // Horas, con fromCache
+}
+
+// target devuelve el host y puerto del análisis, como ScanRequest.Target
+// del analizador, para reconocer dos peticiones al mismo servidor
+func (r ScanRequest) target() string {
+	host, port, err := utils.SplitHostPort(r.Host, 0)
+	if err != nil {
+		return r.Host
+	}
+	return models.ScanRequest{Host: host, Port: port}.Target()
+}
+
+// Job es un análisis encolado o en curso
+type Job struct {
+	ID      string      `json:"id"`
+	Request ScanRequest `json:"request"`
+	State   string      `json:"state"`
+	Error   string      `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"createdAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+
+	// Último estado informado por el backend durante el polling
+	Status    string             `json:"status,omitempty"`
+	Endpoints []EndpointProgress `json:"endpoints,omitempty"`
+
+	result *models.Host
+}
+
+// EndpointProgress es el progreso de un endpoint, como en pollAnalysis
+type EndpointProgress struct {
+	IPAddress     string `json:"ipAddress"`
+	StatusMessage string `json:"statusMessage,omitempty"`
+	Progress      int    `json:"progress"`
+	Grade         string `json:"grade,omitempty"`
+}
+
+// runFunc ejecuta un trabajo e informa el progreso
+type runFunc func(ctx context.Context, req ScanRequest, progress func(*models.Host)) (*models.Host, error)
+
+// queue guarda los trabajos y los ejecuta con un número fijo de workers
+type queue struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job
+	closed bool
+
+	pending chan *Job
+	run     runFunc
+	ttl     time.Duration
+	wg      sync.WaitGroup
+}
+
+func newQueue(size int, ttl time.Duration, run runFunc) *queue {
+	return &queue{
+		jobs:    make(map[string]*Job),
+		pending: make(chan *Job, size),
+		run:     run,
+		ttl:     ttl,
+	}
+}
+
+// start lanza los workers; terminan cuando la cola se cierra y se vacía
+func (q *queue) start(ctx context.Context, workers int) {
+	for range workers {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			for job := range q.pending {
+				q.execute(ctx, job)
+			}
+		}()
+	}
+}
+
+// submit encola un trabajo nuevo
+func (q *queue) submit(req ScanRequest) (*Job, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil, ErrShuttingDown
+	}
+	q.prune(time.Now())
+
+	target := req.target()
+	for _, job := range q.jobs {
+		if (job.State == JobQueued || job.State == JobRunning) && job.Request.target() == target {
+			return nil, fmt.Errorf("%w: %s (job %s)", ErrTargetActive, target, job.ID)
+		}
+	}
+
+	job := &Job{ID: newID(), Request: req, State: JobQueued, CreatedAt: time.Now().UTC()}
+
+	select {
+	case q.pending <- job:
+	default:
+		return nil, ErrQueueFull
+	}
+
+	q.jobs[job.ID] = job
+	return job, nil
+}
+
+// get devuelve una copia del trabajo y su resultado
+func (q *queue) get(id string) (Job, *models.Host, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, nil, false
+	}
+	snapshot := *job
+	snapshot.Endpoints = append([]EndpointProgress(nil), job.Endpoints...)
+	return snapshot, job.result, true
+}
+
+// results devuelve los resultados de los trabajos terminados con éxito
+func (q *queue) results() []*models.Host {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var results []*models.Host
+	for _, job := range q.jobs {
+		if job.State == JobDone && job.result != nil {
+			results = append(results, job.result)
+		}
+	}
+	return results
+}
+
+// close deja de aceptar trabajos; los encolados se siguen ejecutando
+func (q *queue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		close(q.pending)
+	}
+}
+
+// wait espera a que los workers terminen
+func (q *queue) wait() {
+	q.wg.Wait()
+}
+
+func (q *queue) execute(ctx context.Context, job *Job) {
+	q.update(job, func(j *Job) {
+		now := time.Now().UTC()
+		j.State = JobRunning
+		j.StartedAt = &now
+	})
+
+	result, err := q.run(ctx, job.Request, func(status *models.Host) {
+		q.update(job, func(j *Job) { j.setProgress(status) })
+	})
+
+	q.update(job, func(j *Job) {
+		now := time.Now().UTC()
+		j.FinishedAt = &now
+		j.result = result
+		if result != nil {
+			j.setProgress(result)
+		}
+
+		switch {
+		case err == nil:
+			j.State = JobDone
+		case ctx.Err() != nil:
+			j.State = JobCancelled
+			j.Error = err.Error()
+		default:
+			j.State = JobFailed
+			j.Error = err.Error()
+		}
+	})
+}
+
+func (q *queue) update(job *Job, fn func(*Job)) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	fn(job)
+}
+
+// prune olvida los trabajos terminados hace más de ttl
+func (q *queue) prune(now time.Time) {
+	if q.ttl <= 0 {
+		return
+	}
+	for id, job := range q.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > q.ttl {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+func (j *Job) setProgress(status *models.Host) {
+	j.Status = status.Status
+	j.Endpoints = j.Endpoints[:0]
+	for _, ep := range status.Endpoints {
+		j.Endpoints = append(j.Endpoints, EndpointProgress{
+			IPAddress:     ep.IPAddress,
+			StatusMessage: ep.StatusMessage,
+			Progress:      ep.Progress,
+			Grade:         ep.Grade,
+		})
+	}
+}
+
+func newID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
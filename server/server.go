@@ -0,0 +1,304 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"NebulaChallenge/analyzer"
+	"NebulaChallenge/formatter"
+	"NebulaChallenge/metrics"
+	"NebulaChallenge/models"
+	"NebulaChallenge/storage"
+	"NebulaChallenge/utils"
+)
+
+// Valores por defecto del servidor
+const (
+	DefaultWorkers         = 2
+	DefaultQueueSize       = 100
+	DefaultJobTTL          = 24 * time.Hour
+	DefaultShutdownTimeout = 30 * time.Second
+)
+
+// Server expone los análisis como una API REST:
+//
+//	POST /scans               encola un análisis
+//	GET  /scans/{id}          estado y progreso del análisis
+//	GET  /scans/{id}/report   resultado completo en JSON
+//	GET  /metrics             métricas de Prometheus de los últimos resultados
+type Server struct {
+	backend      analyzer.Scanner
+	analyzerOpts []analyzer.Option
+	history      *storage.Store
+	logger       *slog.Logger
+
+	workers         int
+	queueSize       int
+	jobTTL          time.Duration
+	shutdownTimeout time.Duration
+
+	queue *queue
+}
+
+// Option configura un Server en New
+type Option func(*Server)
+
+// WithAnalyzerOptions agrega opciones a cada Analyzer que ejecuta un trabajo
+func WithAnalyzerOptions(opts ...analyzer.Option) Option {
+	return func(s *Server) {
+		s.analyzerOpts = append(s.analyzerOpts, opts...)
+	}
+}
+
+// WithHistory guarda cada evaluación terminada en el historial
+func WithHistory(store *storage.Store) Option {
+	return func(s *Server) {
+		s.history = store
+	}
+}
+
+// WithWorkers define cuántos análisis se ejecutan a la vez
+func WithWorkers(n int) Option {
+	return func(s *Server) {
+		s.workers = n
+	}
+}
+
+// WithQueueSize limita los trabajos pendientes; al llenarse POST /scans
+// responde 503
+func WithQueueSize(n int) Option {
+	return func(s *Server) {
+		s.queueSize = n
+	}
+}
+
+// WithJobTTL define cuánto tiempo se conservan los trabajos terminados
+func WithJobTTL(d time.Duration) Option {
+	return func(s *Server) {
+		s.jobTTL = d
+	}
+}
+
+// WithShutdownTimeout define cuánto se espera a los análisis en curso al
+// apagar el servidor antes de cancelarlos
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.shutdownTimeout = d
+	}
+}
+
+// WithLogger define el logger de peticiones y trabajos
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// New crea un servidor que analiza con backend
+func New(backend analyzer.Scanner, opts ...Option) *Server {
+	s := &Server{
+		backend:         backend,
+		logger:          slog.New(slog.DiscardHandler),
+		workers:         DefaultWorkers,
+		queueSize:       DefaultQueueSize,
+		jobTTL:          DefaultJobTTL,
+		shutdownTimeout: DefaultShutdownTimeout,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.workers < 1 {
+		s.workers = 1
+	}
+	s.queue = newQueue(s.queueSize, s.jobTTL, s.runJob)
+
+	return s
+}
+
+// Handler devuelve el http.Handler con las rutas de la API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scans", s.handleCreate)
+	mux.HandleFunc("GET /scans/{id}", s.handleStatus)
+	mux.HandleFunc("GET /scans/{id}/report", s.handleReport)
+	mux.Handle("GET /metrics", metrics.Handler(s.queue.results))
+	return mux
+}
+
+// ListenAndServe atiende peticiones en addr hasta que ctx se cancela. Al
+// apagarse deja de aceptar conexiones y trabajos, espera a los análisis en
+// curso hasta shutdownTimeout y cancela los que sigan.
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	s.queue.start(jobCtx, s.workers)
+
+	srv := &http.Server{Addr: addr, Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	s.logger.Info("server listening", "addr", addr, "workers", s.workers, "engine", s.backend.Name())
+
+	select {
+	case err := <-errCh:
+		s.queue.close()
+		cancelJobs()
+		s.queue.wait()
+		return err
+	case <-ctx.Done():
+	}
+
+	s.logger.Info("shutting down", "timeout", s.shutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	s.queue.close()
+	err := srv.Shutdown(shutdownCtx)
+
+	done := make(chan struct{})
+	go func() {
+		s.queue.wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		s.logger.Warn("cancelling running scans")
+		cancelJobs()
+		<-done
+	}
+
+	return err
+}
+
+// runJob ejecuta un trabajo con analyzer.Run
+func (s *Server) runJob(ctx context.Context, req ScanRequest, progress func(*models.Host)) (*models.Host, error) {
+	opts := append([]analyzer.Option{}, s.analyzerOpts...)
+	opts = append(opts, analyzer.WithScanner(s.backend), analyzer.WithProgress(progress), analyzer.WithOutput(io.Discard))
+	if req.FromCache {
+		opts = append(opts, analyzer.WithCache(req.MaxAge))
+	}
+
+	s.logger.Info("scan started", "host", req.Host)
+	result, err := analyzer.NewAnalyzer(opts...).Run(ctx, req.Host, req.Publish)
+	if err != nil {
+		s.logger.Warn("scan failed", "host", req.Host, "error", err)
+		return result, err
+	}
+	s.logger.Info("scan finished", "host", req.Host)
+
+	if s.history != nil {
+		if _, err := s.history.Save(result); err != nil {
+			s.logger.Warn("could not save result to history", "host", req.Host, "error", err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if err := validateRequest(req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := s.queue.submit(req)
+	switch {
+	case errors.Is(err, ErrQueueFull), errors.Is(err, ErrShuttingDown):
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	case errors.Is(err, ErrTargetActive):
+		writeError(w, http.StatusConflict, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	snapshot, _, _ := s.queue.get(job.ID)
+	w.Header().Set("Location", "/scans/"+job.ID)
+	writeJSON(w, http.StatusAccepted, snapshot)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	job, _, ok := s.queue.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("scan not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
+	job, result, ok := s.queue.get(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, errors.New("scan not found"))
+		return
+	}
+
+	switch job.State {
+	case JobDone:
+	case JobFailed, JobCancelled:
+		writeError(w, http.StatusConflict, fmt.Errorf("scan %s: %s", job.State, job.Error))
+		return
+	default:
+		writeError(w, http.StatusConflict, fmt.Errorf("scan is %s", job.State))
+		return
+	}
+
+	report, err := formatter.ExportJSON(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, report)
+}
+
+// validateRequest valida el host igual que el CLI
+func validateRequest(req ScanRequest) error {
+	host, _, err := utils.SplitHostPort(req.Host, 0)
+	if err == nil {
+		err = utils.ValidateHost(host)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid host: %w", err)
+	}
+	if req.MaxAge < 0 {
+		return errors.New("maxAge must be zero or positive")
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,444 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"NebulaChallenge/models"
+	"NebulaChallenge/utils"
+)
+
+// idLayout es el formato del ID de cada registro: el TestTime en UTC con
+// milisegundos, que además ordena lexicográficamente
+const idLayout = "20060102T150405.000Z"
+
+// ErrNotFound indica que no hay registros que coincidan con la búsqueda
+var ErrNotFound = errors.New("no history record found")
+
+// Store guarda los resultados de cada evaluación en archivos JSON con la
+// estructura <dir>/<target>/<ip>/<id>.json, es decir, por host, IP del
+// endpoint y TestTime. Cada archivo tiene los campos del host y solo ese
+// endpoint; Load vuelve a juntar los endpoints de una misma evaluación.
+// target es el host con el puerto si no es el 443 (ver Entry.Target); en
+// target e ip ":" se cambia por "_" para que sean nombres de directorio
+// válidos: example.com, example.com_8443, [2001_db8__1]_8443, 2001_db8__2.
+type Store struct {
+	dir string
+}
+
+// defaultPort es el puerto que no se añade a la clave, para que el historial
+// de SSL Labs (siempre 443) conserve un directorio por host
+const defaultPort = 443
+
+// Entry describe un registro del historial sin cargar el resultado completo
+type Entry struct {
+	ID        string          `json:"id"`
+	Host      string          `json:"host"`
+	Port      int             `json:"port"`
+	TestTime  time.Time       `json:"testTime"`
+	Endpoints []EndpointGrade `json:"endpoints"`
+}
+
+// Target devuelve el host con el puerto si no es el 443, tal como se indica
+// en Load y Filter.Host
+func (e Entry) Target() string {
+	return target(e.Host, e.Port)
+}
+
+// EndpointGrade es el grado de un endpoint en un registro
+type EndpointGrade struct {
+	IPAddress string `json:"ipAddress"`
+	Grade     string `json:"grade"`
+}
+
+// Filter limita los registros que devuelve List; los campos vacíos no filtran
+type Filter struct {
+	Host      string // host[:puerto]; sin puerto, el 443
+	IPAddress string
+	Since     time.Time
+}
+
+// DefaultDir devuelve el directorio de historial por defecto
+// (~/.nebula-challenge/history)
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".nebula-challenge", "history")
+	}
+	return filepath.Join(home, ".nebula-challenge", "history")
+}
+
+// Open abre (y crea si no existe) el historial en dir
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating history directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Save guarda un resultado completo, un registro por endpoint. El registro
+// se identifica por host, puerto, IP y TestTime (o la hora actual si el
+// backend no lo informa); guardar dos veces la misma evaluación sobrescribe
+// sus registros.
+func (s *Store) Save(host *models.Host) (Entry, error) {
+	if host.Host == "" {
+		return Entry{}, fmt.Errorf("cannot save a result without host")
+	}
+	if len(host.Endpoints) == 0 {
+		return Entry{}, fmt.Errorf("cannot save a result without endpoints")
+	}
+
+	testTime := time.Now()
+	if host.TestTime > 0 {
+		testTime = time.UnixMilli(host.TestTime)
+	}
+
+	name := dirName(host.Host, host.Port)
+	if err := validName(name); err != nil {
+		return Entry{}, err
+	}
+
+	id := testTime.UTC().Format(idLayout)
+	for _, ep := range host.Endpoints {
+		ip := ipDir(ep.IPAddress)
+		if err := validName(ip); err != nil {
+			return Entry{}, err
+		}
+
+		record := *host
+		record.Endpoints = []models.Endpoint{ep}
+		if err := s.write(filepath.Join(s.dir, name, ip), id, &record); err != nil {
+			return Entry{}, err
+		}
+	}
+
+	return newEntry(id, host, testTime), nil
+}
+
+// write guarda un registro en dir/id.json. Se escribe en un temporal y se
+// renombra para no dejar registros a medias.
+func (s *Store) write(dir, id string, record *models.Host) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("error creating history directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding result: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error saving result: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error saving result: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error saving result: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, id+".json")); err != nil {
+		return fmt.Errorf("error saving result: %w", err)
+	}
+	return nil
+}
+
+// List devuelve las evaluaciones que cumplen el filtro, ordenadas por host y
+// de la más reciente a la más antigua. Con Filter.IPAddress solo se leen los
+// registros de esa IP, pero cada entrada lista todos los endpoints de la
+// evaluación.
+func (s *Store) List(filter Filter) ([]Entry, error) {
+	hosts, err := s.hosts(filter.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	for _, host := range hosts {
+		var ids []string
+		if filter.IPAddress != "" {
+			ip := ipDir(filter.IPAddress)
+			if err := validName(ip); err != nil {
+				return nil, err
+			}
+			ids, err = s.ids(host, ip)
+		} else {
+			ids, err = s.recordIDs(host)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, id := range ids {
+			testTime, _ := time.Parse(idLayout, id)
+			if !filter.Since.IsZero() && testTime.Before(filter.Since) {
+				continue
+			}
+
+			result, err := s.load(host, id)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, newEntry(id, result, testTime))
+		}
+	}
+
+	return entries, nil
+}
+
+// Load lee una evaluación de host[:puerto] con todos sus endpoints. id puede
+// ser "latest" para la más reciente.
+func (s *Store) Load(host, id string) (*models.Host, error) {
+	name, err := targetDir(host)
+	if err != nil {
+		return nil, err
+	}
+	return s.load(name, id)
+}
+
+// load junta los registros de cada IP de la evaluación id del directorio host
+func (s *Store) load(host, id string) (*models.Host, error) {
+	if id == "" || id == "latest" {
+		ids, err := s.recordIDs(host)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("%w for %s", ErrNotFound, host)
+		}
+		id = ids[0]
+	} else if err := validName(id); err != nil {
+		return nil, err
+	}
+
+	ips, err := s.ips(host)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *models.Host
+	for _, ip := range ips {
+		data, err := os.ReadFile(s.path(host, ip, id))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading history record: %w", err)
+		}
+
+		var record models.Host
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, fmt.Errorf("error parsing history record %s %s %s: %w", host, ip, id, err)
+		}
+
+		if result == nil {
+			result = &record
+		} else {
+			result.Endpoints = append(result.Endpoints, record.Endpoints...)
+		}
+	}
+
+	if result == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNotFound, host, id)
+	}
+	return result, nil
+}
+
+// Prune borra los registros anteriores a before, conservando siempre los
+// keep más recientes de cada host e IP. Devuelve el número de registros
+// borrados.
+func (s *Store) Prune(before time.Time, keep int) (int, error) {
+	hosts, err := s.hosts("")
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, host := range hosts {
+		ips, err := s.ips(host)
+		if err != nil {
+			return removed, err
+		}
+
+		for _, ip := range ips {
+			ids, err := s.ids(host, ip)
+			if err != nil {
+				return removed, err
+			}
+
+			for i, id := range ids {
+				testTime, err := time.Parse(idLayout, id)
+				if i < keep || err != nil || !testTime.Before(before) {
+					continue
+				}
+				if err := os.Remove(s.path(host, ip, id)); err != nil {
+					return removed, fmt.Errorf("error pruning history: %w", err)
+				}
+				removed++
+			}
+
+			// Eliminar el directorio de la IP si quedó vacío
+			os.Remove(filepath.Join(s.dir, host, ip))
+		}
+
+		// Y el del host
+		os.Remove(filepath.Join(s.dir, host))
+	}
+
+	return removed, nil
+}
+
+// hosts devuelve los directorios con registros, o solo el de host si se indica
+func (s *Store) hosts(host string) ([]string, error) {
+	if host != "" {
+		name, err := targetDir(host)
+		if err != nil {
+			return nil, err
+		}
+		return []string{name}, nil
+	}
+
+	dirEntries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	var hosts []string
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			hosts = append(hosts, de.Name())
+		}
+	}
+	return hosts, nil
+}
+
+// ips devuelve los directorios de IP de un host
+func (s *Store) ips(host string) ([]string, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(s.dir, host))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	var ips []string
+	for _, de := range dirEntries {
+		if de.IsDir() {
+			ips = append(ips, de.Name())
+		}
+	}
+	return ips, nil
+}
+
+// recordIDs devuelve los IDs de las evaluaciones de un host, sumando los de
+// todas sus IPs, de la más reciente a la más antigua
+func (s *Store) recordIDs(host string) ([]string, error) {
+	ips, err := s.ips(host)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, ip := range ips {
+		ipIDs, err := s.ids(host, ip)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, ipIDs...)
+	}
+
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+// ids devuelve los IDs de los registros de un host e IP, del más reciente al
+// más antiguo
+func (s *Store) ids(host, ip string) ([]string, error) {
+	dirEntries, err := os.ReadDir(filepath.Join(s.dir, host, ip))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
+
+	var ids []string
+	for _, de := range dirEntries {
+		name := de.Name()
+		if de.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, ".json"))
+	}
+
+	slices.Sort(ids)
+	slices.Reverse(ids)
+	return ids, nil
+}
+
+func (s *Store) path(host, ip, id string) string {
+	return filepath.Join(s.dir, host, ip, id+".json")
+}
+
+// targetDir devuelve el directorio de un host[:puerto] recibido del usuario
+func targetDir(raw string) (string, error) {
+	if err := validName(raw); err != nil {
+		return "", err
+	}
+	host, port, err := utils.SplitHostPort(raw, 0)
+	if err != nil {
+		return "", fmt.Errorf("invalid history key %q: %w", raw, err)
+	}
+
+	name := dirName(host, port)
+	if err := validName(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// dirName es el nombre del directorio de host y puerto (ver Store)
+func dirName(host string, port int) string {
+	return strings.ReplaceAll(target(host, port), ":", "_")
+}
+
+// ipDir es el nombre del directorio de la IP de un endpoint (ver Store)
+func ipDir(ip string) string {
+	return strings.ReplaceAll(ip, ":", "_")
+}
+
+// target es el host con el puerto, salvo que sea el 443 o no se conozca
+func target(host string, port int) string {
+	if port == defaultPort {
+		port = 0
+	}
+	return models.ScanRequest{Host: host, Port: port}.Target()
+}
+
+// validName evita que un host o ID recibido del usuario salga del directorio
+func validName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid history key %q", name)
+	}
+	return nil
+}
+
+func newEntry(id string, host *models.Host, testTime time.Time) Entry {
+	entry := Entry{ID: id, Host: host.Host, Port: host.Port, TestTime: testTime}
+	for _, ep := range host.Endpoints {
+		entry.Endpoints = append(entry.Endpoints, EndpointGrade{IPAddress: ep.IPAddress, Grade: ep.Grade})
+	}
+	return entry
+}
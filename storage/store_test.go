@@ -0,0 +1,286 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"NebulaChallenge/models"
+)
+
+// evaluation arma un resultado con un endpoint por IP
+func evaluation(host string, port int, testTime time.Time, ips ...string) *models.Host {
+	h := &models.Host{Host: host, Port: port, TestTime: testTime.UnixMilli()}
+	for _, ip := range ips {
+		h.Endpoints = append(h.Endpoints, models.Endpoint{IPAddress: ip, Grade: "A"})
+	}
+	return h
+}
+
+func openStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func save(t *testing.T, s *Store, hosts ...*models.Host) {
+	t.Helper()
+	for _, h := range hosts {
+		if _, err := s.Save(h); err != nil {
+			t.Fatalf("Save(%s) error = %v", h.Host, err)
+		}
+	}
+}
+
+func entryIDs(entries []Entry) []string {
+	var ids []string
+	for _, e := range entries {
+		ids = append(ids, e.Target()+" "+e.ID)
+	}
+	return ids
+}
+
+func TestSaveLayout(t *testing.T) {
+	t1 := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		host  *models.Host
+		files []string
+	}{
+		{"default port", evaluation("example.com", 443, t1, "192.0.2.1", "2001:db8::1"), []string{
+			"example.com/192.0.2.1/20250115T100000.000Z.json",
+			"example.com/2001_db8__1/20250115T100000.000Z.json",
+		}},
+		{"unknown port", evaluation("example.com", 0, t1, "192.0.2.1"), []string{
+			"example.com/192.0.2.1/20250115T100000.000Z.json",
+		}},
+		{"other port", evaluation("example.com", 8443, t1, "192.0.2.1"), []string{
+			"example.com_8443/192.0.2.1/20250115T100000.000Z.json",
+		}},
+		{"IPv6 host", evaluation("2001:db8::1", 8443, t1, "2001:db8::1"), []string{
+			"[2001_db8__1]_8443/2001_db8__1/20250115T100000.000Z.json",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openStore(t)
+			entry, err := s.Save(tt.host)
+			if err != nil {
+				t.Fatalf("Save() error = %v", err)
+			}
+			if entry.ID != "20250115T100000.000Z" || len(entry.Endpoints) != len(tt.host.Endpoints) {
+				t.Errorf("Save() entry = %+v", entry)
+			}
+			for _, file := range tt.files {
+				if _, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(file))); err != nil {
+					t.Errorf("missing record: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveInvalid(t *testing.T) {
+	t1 := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		host *models.Host
+	}{
+		{"no host", evaluation("", 443, t1, "192.0.2.1")},
+		{"no endpoints", evaluation("example.com", 443, t1)},
+		{"host with path", evaluation("../etc", 443, t1, "192.0.2.1")},
+		{"IP with path", evaluation("example.com", 443, t1, "../../x")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openStore(t).Save(tt.host); err == nil {
+				t.Error("Save() succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t1 := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+
+	s := openStore(t)
+	save(t, s,
+		evaluation("example.com", 443, t1, "192.0.2.1", "2001:db8::1"),
+		evaluation("example.com", 443, t2, "192.0.2.1"),
+		evaluation("example.com", 8443, t2, "192.0.2.9"),
+	)
+
+	tests := []struct {
+		target, id string
+		wantIPs    []string
+		wantErr    bool
+	}{
+		{"example.com", "20250115T100000.000Z", []string{"192.0.2.1", "2001:db8::1"}, false},
+		{"example.com:443", "latest", []string{"192.0.2.1"}, false},
+		{"example.com", "", []string{"192.0.2.1"}, false},
+		{"example.com:8443", "latest", []string{"192.0.2.9"}, false},
+		{"example.com", "20240101T000000.000Z", nil, true},
+		{"example.org", "latest", nil, true},
+		{"example.com", "../x", nil, true},
+		{"../example.com", "latest", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.id, func(t *testing.T) {
+			result, err := s.Load(tt.target, tt.id)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("Load() succeeded, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			var ips []string
+			for _, ep := range result.Endpoints {
+				ips = append(ips, ep.IPAddress)
+			}
+			slices.Sort(ips)
+			if !slices.Equal(ips, tt.wantIPs) {
+				t.Errorf("Load() endpoints = %q, want %q", ips, tt.wantIPs)
+			}
+		})
+	}
+
+	if _, err := s.Load("example.org", "latest"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() of an unknown host error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	t1 := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+
+	s := openStore(t)
+	save(t, s,
+		evaluation("example.com", 443, t1, "192.0.2.1", "2001:db8::1"),
+		evaluation("example.com", 443, t2, "192.0.2.1"),
+		evaluation("example.org", 8443, t1, "192.0.2.9"),
+	)
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []string
+	}{
+		{"all", Filter{}, []string{
+			"example.com 20250116T100000.000Z",
+			"example.com 20250115T100000.000Z",
+			"example.org:8443 20250115T100000.000Z",
+		}},
+		{"host", Filter{Host: "example.com"}, []string{
+			"example.com 20250116T100000.000Z",
+			"example.com 20250115T100000.000Z",
+		}},
+		{"host without port", Filter{Host: "example.org"}, nil},
+		{"host and port", Filter{Host: "example.org:8443"}, []string{
+			"example.org:8443 20250115T100000.000Z",
+		}},
+		{"IP", Filter{Host: "example.com", IPAddress: "2001:db8::1"}, []string{
+			"example.com 20250115T100000.000Z",
+		}},
+		{"since", Filter{Since: t2}, []string{
+			"example.com 20250116T100000.000Z",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := s.List(tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if got := entryIDs(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Cada entrada lista todos los endpoints de la evaluación, aunque se
+	// filtre por una IP
+	entries, err := s.List(Filter{IPAddress: "2001:db8::1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || len(entries[0].Endpoints) != 2 {
+		t.Errorf("List() by IP = %+v, want one entry with both endpoints", entries)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t1 := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	t2 := t1.Add(24 * time.Hour)
+	t3 := t2.Add(24 * time.Hour)
+
+	tests := []struct {
+		name        string
+		before      time.Time
+		keep        int
+		wantRemoved int
+		want        []string
+	}{
+		{"nothing older", t1, 0, 0, []string{
+			"example.com 20250117T100000.000Z",
+			"example.com 20250116T100000.000Z",
+			"example.com 20250115T100000.000Z",
+			"example.org 20250115T100000.000Z",
+		}},
+		// La evaluación de t1 sigue siendo la última de 2001:db8::1
+		{"keep one per IP", t3.Add(time.Hour), 1, 2, []string{
+			"example.com 20250117T100000.000Z",
+			"example.com 20250115T100000.000Z",
+			"example.org 20250115T100000.000Z",
+		}},
+		{"older than t2", t2, 0, 4, []string{
+			"example.com 20250117T100000.000Z",
+			"example.com 20250116T100000.000Z",
+		}},
+		{"everything", t3.Add(time.Hour), 0, 6, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := openStore(t)
+			save(t, s,
+				evaluation("example.com", 443, t1, "192.0.2.1", "2001:db8::1"),
+				evaluation("example.com", 443, t2, "192.0.2.1"),
+				evaluation("example.com", 443, t3, "192.0.2.1"),
+				evaluation("example.org", 443, t1, "192.0.2.9", "192.0.2.10"),
+			)
+
+			removed, err := s.Prune(tt.before, tt.keep)
+			if err != nil {
+				t.Fatalf("Prune() error = %v", err)
+			}
+			if removed != tt.wantRemoved {
+				t.Errorf("Prune() removed %d records, want %d", removed, tt.wantRemoved)
+			}
+
+			entries, err := s.List(Filter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := entryIDs(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("List() after Prune = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadHosts lee una lista de hosts, uno por línea.
+// Ignora líneas vacías y comentarios que empiezan con '#'.
+func ReadHosts(r io.Reader) ([]string, error) {
+	var hosts []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		hosts = append(hosts, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading hosts: %w", err)
+	}
+
+	return hosts, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -24,8 +25,10 @@ func ValidateHost(host string) error {
 		host = host[:idx]
 	}
 
-	// Remover puerto si lo tiene
-	if idx := strings.Index(host, ":"); idx != -1 {
+	// Remover puerto si lo tiene (también [IPv6]:puerto)
+	if h, _, err := SplitHostPort(host, 0); err == nil {
+		host = h
+	} else if idx := strings.Index(host, ":"); idx != -1 {
 		host = host[:idx]
 	}
 
@@ -56,6 +59,10 @@ func isValidDomain(domain string) bool {
 
 // SanitizeHost limpia el host de protocolos, paths y puertos
 func SanitizeHost(host string) string {
+	if h, _, err := SplitHostPort(host, 0); err == nil {
+		return h
+	}
+
 	host = strings.TrimSpace(host)
 	host = strings.TrimPrefix(host, "http://")
 	host = strings.TrimPrefix(host, "https://")
@@ -70,3 +77,55 @@ func SanitizeHost(host string) string {
 
 	return host
 }
+
+// SplitHostPort limpia el host como SanitizeHost pero conserva el puerto.
+// Si no se indica puerto, devuelve defaultPort. Una IPv6 con puerto se
+// escribe entre corchetes ([2001:db8::1]:8443); sin corchetes una dirección
+// con varios ":" se toma entera como host.
+func SplitHostPort(host string, defaultPort int) (string, int, error) {
+	host = strings.TrimSpace(host)
+	host = strings.TrimPrefix(host, "http://")
+	host = strings.TrimPrefix(host, "https://")
+
+	if idx := strings.Index(host, "/"); idx != -1 {
+		host = host[:idx]
+	}
+
+	switch {
+	case strings.HasPrefix(host, "["):
+		end := strings.Index(host, "]")
+		if end == -1 {
+			return "", 0, fmt.Errorf("missing ']' in %s", host)
+		}
+		addr, rest := host[1:end], host[end+1:]
+		if rest == "" {
+			return addr, defaultPort, nil
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return "", 0, fmt.Errorf("invalid port in %s", host)
+		}
+		port, err := parsePort(rest[1:], host)
+		return addr, port, err
+	case strings.Count(host, ":") > 1:
+		return host, defaultPort, nil
+	}
+
+	idx := strings.LastIndex(host, ":")
+	if idx == -1 {
+		return host, defaultPort, nil
+	}
+
+	port, err := parsePort(host[idx+1:], host)
+	if err != nil {
+		return "", 0, err
+	}
+	return host[:idx], port, nil
+}
+
+func parsePort(s, host string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port in %s", host)
+	}
+	return port, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"NebulaChallenge/analyzer"
+	"NebulaChallenge/storage"
+	"NebulaChallenge/watch"
+)
+
+// runWatch implementa el comando "watch": vuelve a analizar los hosts según
+// --schedule y envía alertas a los sinks de --alert
+func runWatch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+
+	var hosts, alerts hostList
+	fs.Var(&hosts, "host", "Hostname to watch (repeatable)")
+	fs.Var(&alerts, "alert", "Alert sink: stdout, file:<path> or webhook:<url> (repeatable, default stdout)")
+	hostsFile := fs.String("hosts-file", "", "File with one hostname per line ('-' for stdin)")
+	schedule := fs.String("schedule", "@daily", "When to re-assess: @every <duration>, @hourly, @daily, @weekly or a 5-field cron expression")
+	certWarnDays := fs.Int("cert-warn-days", 30, "Alert when a certificate expires within this many days")
+	concurrency := fs.Int("concurrency", 4, "Maximum concurrent assessments per round")
+	maxWait := fs.Duration("max-wait", 0, "Maximum total wait per host (0 = no limit)")
+	historyDir := fs.String("history-dir", storage.DefaultDir(), "Directory where completed assessments are saved")
+	noHistory := fs.Bool("no-history", false, "Do not save assessments nor load the previous results from the history")
+	metricsFile := fs.String("metrics-file", "", "Rewrite this Prometheus textfile after every round")
+	verbose := fs.Bool("verbose", false, "Log retries, rate-limit waits and polling to stderr")
+
+	var engines engineConfig
+	engines.register(fs)
+
+	if fs.Parse(args) != nil {
+		return exitError
+	}
+
+	allHosts, err := collectHosts(append(hosts, fs.Args()...), *hostsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+	if len(allHosts) == 0 {
+		fmt.Fprintln(os.Stderr, "Usage: watch [options] --host=<hostname> [--host=<hostname>...]")
+		return exitError
+	}
+
+	sched, err := watch.ParseSchedule(*schedule)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	if len(alerts) == 0 {
+		alerts = hostList{"stdout"}
+	}
+	var sinks []watch.Sink
+	for _, spec := range alerts {
+		sink, err := watch.ParseSink(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitError
+		}
+		sinks = append(sinks, sink)
+	}
+
+	// Un proceso de larga duración registra siempre las rondas
+	level := slog.LevelInfo
+	if *verbose {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	engines.logger = logger
+	backend, err := engines.build()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitError
+	}
+
+	a := analyzer.NewAnalyzer(
+		analyzer.WithScanner(backend),
+		analyzer.WithMaxWait(*maxWait),
+		analyzer.WithLogger(logger),
+	)
+
+	opts := []watch.Option{
+		watch.WithSinks(sinks...),
+		watch.WithCertWarning(time.Duration(*certWarnDays) * 24 * time.Hour),
+		watch.WithConcurrency(*concurrency),
+		watch.WithMetricsFile(*metricsFile),
+		watch.WithLogger(logger),
+	}
+	if !*noHistory {
+		store, err := storage.Open(*historyDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: history disabled: %v\n", err)
+		} else {
+			opts = append(opts, watch.WithHistory(store))
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err = watch.New(a, allHosts, sched, opts...).Run(ctx)
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintln(os.Stderr, "Watch stopped")
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return exitError
+}
@@ -0,0 +1,100 @@
+package watch
+
+import (
+	"fmt"
+	"time"
+
+	"NebulaChallenge/diff"
+	"NebulaChallenge/models"
+	"NebulaChallenge/policy"
+)
+
+// Tipos de evento
+const (
+	EventGradeDrop        = "grade_drop"
+	EventCertExpiry       = "cert_expiry"
+	EventNewVulnerability = "new_vulnerability"
+	EventScanFailed       = "scan_failed"
+)
+
+// Event es una alerta generada al comparar una evaluación con la anterior
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Host     string    `json:"host"`
+	Endpoint string    `json:"endpoint,omitempty"`
+	Old      string    `json:"old,omitempty"`
+	New      string    `json:"new,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// Detect compara current con previous (nil en la primera evaluación) y
+// devuelve los eventos a notificar. Un certificado genera evento cuando
+// entra en la ventana de certWarn antes del vencimiento.
+func Detect(previous, current *models.Host, certWarn time.Duration, now time.Time) []Event {
+	var events []Event
+	add := func(typ, ip, old, new, msg string) {
+		events = append(events, Event{Time: now, Type: typ, Host: current.Host, Endpoint: ip, Old: old, New: new, Message: msg})
+	}
+
+	if previous != nil {
+		for _, change := range diff.Compare(previous, current).Changes {
+			switch {
+			case change.Kind == diff.KindGrade && gradeDropped(change.Old, change.New):
+				add(EventGradeDrop, change.Endpoint, change.Old, change.New,
+					fmt.Sprintf("%s %s: %s", current.Host, change.Endpoint, change.Message))
+			case change.Kind == diff.KindVulnerability && change.New == "yes":
+				add(EventNewVulnerability, change.Endpoint, change.Old, change.New,
+					fmt.Sprintf("%s %s: %s", current.Host, change.Endpoint, change.Message))
+			}
+		}
+	}
+
+	for _, ep := range current.Endpoints {
+		if ep.Details == nil || !expiringSoon(ep.Details.Cert, certWarn, now) {
+			continue
+		}
+		// Solo se avisa al entrar en la ventana, no en cada evaluación
+		if prev := findEndpoint(previous, ep.IPAddress); prev != nil && prev.Details != nil &&
+			prev.Details.Cert.NotAfter == ep.Details.Cert.NotAfter && expiringSoon(prev.Details.Cert, certWarn, now) {
+			continue
+		}
+
+		cert := "certificate"
+		if ep.Details.Cert.Subject != "" {
+			cert += " " + ep.Details.Cert.Subject
+		}
+		notAfter := time.UnixMilli(ep.Details.Cert.NotAfter)
+		days := int(notAfter.Sub(now).Hours() / 24)
+		add(EventCertExpiry, ep.IPAddress, "", notAfter.UTC().Format(time.RFC3339),
+			fmt.Sprintf("%s %s: %s expires in %d days (%s)",
+				current.Host, ep.IPAddress, cert, days, notAfter.Format("2006-01-02")))
+	}
+
+	return events
+}
+
+// gradeDropped indica si el grado empeoró. Perder el grado también cuenta.
+func gradeDropped(old, new string) bool {
+	oldRank, newRank := policy.GradeRank(old), policy.GradeRank(new)
+	if oldRank < 0 {
+		return false
+	}
+	return newRank < 0 || newRank > oldRank
+}
+
+func expiringSoon(cert models.Cert, window time.Duration, now time.Time) bool {
+	return cert.NotAfter > 0 && time.UnixMilli(cert.NotAfter).Sub(now) < window
+}
+
+func findEndpoint(host *models.Host, ip string) *models.Endpoint {
+	if host == nil {
+		return nil
+	}
+	for i := range host.Endpoints {
+		if host.Endpoints[i].IPAddress == ip {
+			return &host.Endpoints[i]
+		}
+	}
+	return nil
+}
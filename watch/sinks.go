@@ -0,0 +1,140 @@
+package watch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink recibe los eventos de alerta
+type Sink interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// WriterSink escribe cada evento como una línea de texto
+type WriterSink struct {
+	w io.Writer
+}
+
+// NewWriterSink crea un sink que escribe en w (p. ej. os.Stdout)
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Send escribe el evento
+func (s *WriterSink) Send(ctx context.Context, event Event) error {
+	_, err := fmt.Fprintf(s.w, "%s [%s] %s\n", event.Time.Format(time.RFC3339), event.Type, event.Message)
+	return err
+}
+
+// FileSink agrega cada evento como una línea JSON a un archivo
+type FileSink struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileSink crea un sink que escribe en path (se crea si no existe)
+func NewFileSink(path string) *FileSink {
+	return &FileSink{path: path}
+}
+
+// Send agrega el evento al archivo
+func (s *FileSink) Send(ctx context.Context, event Event) error {
+	data, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("error opening alert file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("error writing alert file: %w", err)
+	}
+	return nil
+}
+
+// WebhookSink envía cada evento como JSON por POST a una URL
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookSink crea un sink que publica en url
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{url: url, httpClient: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Send publica el evento. Cualquier respuesta que no sea 2xx es un error.
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	data, err := encodeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("error creating webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// encodeEvent codifica el evento como una línea JSON sin escapar HTML, para
+// que los mensajes ("B -> C") queden legibles
+func encodeEvent(event Event) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(event); err != nil {
+		return nil, fmt.Errorf("error encoding event: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseSink crea un sink a partir de "stdout", "file:<path>" o
+// "webhook:<url>"
+func ParseSink(spec string) (Sink, error) {
+	switch {
+	case spec == "stdout":
+		return NewWriterSink(os.Stdout), nil
+	case strings.HasPrefix(spec, "file:"):
+		path := strings.TrimPrefix(spec, "file:")
+		if path == "" {
+			return nil, fmt.Errorf("invalid alert sink %q: missing file path", spec)
+		}
+		return NewFileSink(path), nil
+	case strings.HasPrefix(spec, "webhook:"):
+		url := strings.TrimPrefix(spec, "webhook:")
+		if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+			return nil, fmt.Errorf("invalid alert sink %q: webhook URL must be http or https", spec)
+		}
+		return NewWebhookSink(url), nil
+	default:
+		return nil, fmt.Errorf("unknown alert sink %q (use stdout, file:<path> or webhook:<url>)", spec)
+	}
+}
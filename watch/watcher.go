@@ -0,0 +1,236 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"NebulaChallenge/analyzer"
+	"NebulaChallenge/metrics"
+	"NebulaChallenge/models"
+	"NebulaChallenge/storage"
+	"NebulaChallenge/utils"
+)
+
+// DefaultCertWarning es la anticipación con la que se avisa del vencimiento
+// de un certificado
+const DefaultCertWarning = 30 * 24 * time.Hour
+
+// Watcher vuelve a analizar un conjunto de hosts según una planificación y
+// envía alertas cuando un resultado empeora respecto del anterior
+type Watcher struct {
+	analyzer    *analyzer.Analyzer
+	hosts       []string
+	schedule    Schedule
+	sinks       []Sink
+	history     *storage.Store
+	certWarning time.Duration
+	concurrency int
+	metricsFile string
+	logger      *slog.Logger
+
+	previous map[string]*models.Host // Último resultado por host
+	failing  map[string]bool         // Hosts cuya última evaluación falló
+}
+
+// Option configura un Watcher en New
+type Option func(*Watcher)
+
+// WithSinks define dónde se envían las alertas
+func WithSinks(sinks ...Sink) Option {
+	return func(w *Watcher) {
+		w.sinks = sinks
+	}
+}
+
+// WithHistory guarda cada evaluación en el historial y lo usa al arrancar
+// como resultado anterior de cada host
+func WithHistory(store *storage.Store) Option {
+	return func(w *Watcher) {
+		w.history = store
+	}
+}
+
+// WithCertWarning define cuánto antes del vencimiento se avisa
+func WithCertWarning(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.certWarning = d
+	}
+}
+
+// WithConcurrency limita las evaluaciones simultáneas de cada ronda
+func WithConcurrency(n int) Option {
+	return func(w *Watcher) {
+		w.concurrency = n
+	}
+}
+
+// WithMetricsFile reescribe al final de cada ronda un archivo de métricas
+// de Prometheus con el último resultado de cada host
+func WithMetricsFile(path string) Option {
+	return func(w *Watcher) {
+		w.metricsFile = path
+	}
+}
+
+// WithLogger define el logger de rondas y errores de los sinks
+func WithLogger(logger *slog.Logger) Option {
+	return func(w *Watcher) {
+		w.logger = logger
+	}
+}
+
+// New crea un Watcher. Sin sinks las alertas se descartan.
+func New(a *analyzer.Analyzer, hosts []string, schedule Schedule, opts ...Option) *Watcher {
+	w := &Watcher{
+		analyzer:    a,
+		hosts:       hosts,
+		schedule:    schedule,
+		certWarning: DefaultCertWarning,
+		concurrency: 1,
+		logger:      slog.New(slog.DiscardHandler),
+		previous:    make(map[string]*models.Host),
+		failing:     make(map[string]bool),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return w
+}
+
+// Run ejecuta una ronda inmediatamente y luego según la planificación,
+// hasta que ctx se cancele
+func (w *Watcher) Run(ctx context.Context) error {
+	w.seed()
+
+	for {
+		w.Round(ctx)
+
+		next := w.schedule.Next(time.Now())
+		if next.IsZero() {
+			return errors.New("schedule has no next run")
+		}
+		w.logger.Info("next watch round", "at", next.Format(time.RFC3339))
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Round analiza todos los hosts una vez, compara cada resultado con el
+// anterior y envía los eventos. Las rondas nunca se solapan: la siguiente
+// se planifica cuando termina la actual.
+func (w *Watcher) Round(ctx context.Context) {
+	start := time.Now()
+	w.logger.Info("watch round started", "hosts", len(w.hosts))
+
+	batch, err := w.analyzer.RunBatch(ctx, w.hosts, false, w.concurrency)
+	if batch == nil {
+		w.logger.Error("watch round failed", "error", err)
+		return
+	}
+
+	events := 0
+	for i, entry := range batch.Results {
+		key := hostKey(w.hosts[i])
+
+		if !entry.Success() {
+			if ctx.Err() != nil {
+				continue
+			}
+			if !w.failing[key] {
+				events++
+				w.emit(ctx, Event{
+					Time:    time.Now(),
+					Type:    EventScanFailed,
+					Host:    entry.Host,
+					Message: fmt.Sprintf("%s: assessment failed: %s", entry.Host, entry.Error),
+				})
+			}
+			w.failing[key] = true
+			continue
+		}
+		w.failing[key] = false
+
+		for _, event := range Detect(w.previous[key], entry.Result, w.certWarning, time.Now()) {
+			events++
+			w.emit(ctx, event)
+		}
+		w.previous[key] = entry.Result
+
+		if w.history != nil {
+			if _, err := w.history.Save(entry.Result); err != nil {
+				w.logger.Warn("could not save result to history", "host", entry.Host, "error", err)
+			}
+		}
+	}
+
+	if w.metricsFile != "" {
+		if err := metrics.WriteFile(w.metricsFile, w.latest()); err != nil {
+			w.logger.Warn("could not write metrics", "error", err)
+		}
+	}
+
+	w.logger.Info("watch round finished",
+		"duration", time.Since(start).Round(time.Second), "failed", batch.Failed(), "events", events)
+}
+
+// latest devuelve el último resultado conocido de cada host, en el orden
+// de la lista
+func (w *Watcher) latest() []*models.Host {
+	var hosts []*models.Host
+	for _, raw := range w.hosts {
+		if host, ok := w.previous[hostKey(raw)]; ok {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// emit envía el evento a todos los sinks. Un sink que falla no impide
+// enviarlo a los demás.
+func (w *Watcher) emit(ctx context.Context, event Event) {
+	for _, sink := range w.sinks {
+		if err := sink.Send(ctx, event); err != nil {
+			w.logger.Error("could not send alert", "type", event.Type, "host", event.Host, "error", err)
+		}
+	}
+}
+
+// seed carga del historial el último resultado de cada host para que la
+// primera ronda tras un reinicio también detecte regresiones
+func (w *Watcher) seed() {
+	if w.history == nil {
+		return
+	}
+
+	for _, raw := range w.hosts {
+		key := hostKey(raw)
+		if _, ok := w.previous[key]; ok {
+			continue
+		}
+		if last, err := w.history.Load(key, "latest"); err == nil {
+			w.previous[key] = last
+		}
+	}
+}
+
+// hostKey normaliza un host de la lista para usarlo como clave. Incluye el
+// puerto (como ScanRequest.Target) para que example.com y example.com:8443
+// tengan su propio estado.
+func hostKey(raw string) string {
+	host, port, err := utils.SplitHostPort(raw, 0)
+	if err != nil {
+		return raw
+	}
+	return models.ScanRequest{Host: host, Port: port}.Target()
+}